@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"gositemap/sitemap"
+	"io"
+	"os"
+)
+
+// runCheck compares the URLs about to be written with the static build and
+// fails when a URL has no prerendered file or a page is missing.
+func runCheck(stdout io.Writer, opts CLIOptions, cfg *sitemap.Config, base string, urls []sitemap.Entry) error {
+	buildDir := cfg.BuildDir
+	if opts.BuildDir != "" {
+		buildDir = opts.BuildDir
+	}
+	result, err := sitemap.CheckBuild(buildDir, base, urls, sitemap.BuildOptions{
+		Exclude:        cfg.Exclude,
+		PrerenderedDir: cfg.Path(sitemap.DefaultPrerendered),
+		TrailingSlash:  cfg.TrailingSlash,
+	})
+	if os.IsNotExist(err) {
+		return fmt.Errorf(Red+"Build directory '%s' not found: build your site before running check"+Reset, buildDir)
+	} else if err != nil {
+		return fmt.Errorf(Red+"Error checking build output in %s: %w"+Reset, buildDir, err)
+	}
+
+	for _, loc := range result.Missing {
+		fmt.Fprintf(stdout, Red+"Not found in build (would 404): %s"+Reset+"\n", loc)
+	}
+	for _, page := range result.Unlisted {
+		fmt.Fprintf(stdout, Yellow+"Prerendered but not in sitemap: %s"+Reset+"\n", page)
+	}
+	if !result.OK() {
+		return fmt.Errorf(Red+"%d URL(s) not found in build, %d page(s) missing from the sitemap"+Reset, len(result.Missing), len(result.Unlisted))
+	}
+	if !opts.Quiet {
+		fmt.Fprintf(stdout, Green+"All %d sitemap URLs match the build in %s"+Reset+"\n", len(urls), buildDir)
+	}
+	return nil
+}
@@ -3,23 +3,62 @@ package main
 import (
 	"flag"
 	"fmt"
+	"gositemap/sitemap"
 	"os"
+	"path/filepath"
+	"time"
 )
 
+// commands are the subcommands accepted as first argument.
+var commands = []string{"validate", "check", "watch"}
+
 type CLIOptions struct {
+	// Command is the subcommand, empty to generate the sitemap.
+	Command string
+	// Args are the arguments left after the flags.
+	Args   []string
 	DryRun bool
 	Quiet  bool
+	Gzip   bool
 	Help   bool
+	// Config, Root and Out override the config file, project root and output
+	// path.
+	Config string
+	Root   string
+	Out    string
+	// BuildDir overrides build_dir for the check command.
+	BuildDir string
+	// Jobs overrides jobs, the number of scans run at once.
+	Jobs int
+	// Interval is the polling interval of the watch command.
+	Interval time.Duration
 }
 
 func ParseCLI(args []string) CLIOptions {
 	opts := CLIOptions{}
+	if len(args) > 0 {
+		for _, c := range commands {
+			if args[0] == c {
+				opts.Command = c
+				args = args[1:]
+				break
+			}
+		}
+	}
 	flagSet := flag.NewFlagSet("gositemap", flag.ExitOnError)
 	flagSet.BoolVar(&opts.DryRun, "dry-run", false, "Print sitemap to stdout instead of writing to file")
 	flagSet.BoolVar(&opts.Quiet, "quiet", false, "Suppress all output except errors")
+	flagSet.BoolVar(&opts.Gzip, "gzip", false, "Also write a gzip-compressed sitemap.xml.gz")
+	flagSet.StringVar(&opts.Config, "config", "", "Path to gositemap.toml (default: <root>/gositemap.toml)")
+	flagSet.StringVar(&opts.Root, "root", "", "SvelteKit project root (overrides project_root)")
+	flagSet.StringVar(&opts.Out, "out", "", "Sitemap output path (overrides output_path)")
+	flagSet.StringVar(&opts.BuildDir, "build-dir", "", "Build directory to check against (overrides build_dir)")
+	flagSet.IntVar(&opts.Jobs, "jobs", 0, "Number of directories scanned at once (default: number of CPUs)")
+	flagSet.DurationVar(&opts.Interval, "interval", sitemap.DefaultWatchInterval, "Polling interval of the watch command")
 	flagSet.BoolVar(&opts.Help, "help", false, "Show help and exit")
 	flagSet.BoolVar(&opts.Help, "h", false, "Show help and exit (shorthand)")
 	flagSet.Parse(args)
+	opts.Args = flagSet.Args()
 
 	if opts.Help {
 		printHelp()
@@ -29,23 +68,86 @@ func ParseCLI(args []string) CLIOptions {
 	return opts
 }
 
+// configPath returns the config file: --config, or gositemap.toml in the
+// project root.
+func (opts CLIOptions) configPath() string {
+	if opts.Config != "" {
+		return opts.Config
+	}
+	return filepath.Join(opts.Root, "gositemap.toml")
+}
+
+// resolvePaths makes the paths of cfg relative to the project root and returns
+// the sitemap output path. --root wins over project_root, which is relative to
+// the config file, and --out wins over output_path. --jobs replaces jobs.
+func (opts CLIOptions) resolvePaths(cfg *sitemap.Config, configPath string) string {
+	root := opts.Root
+	if root == "" {
+		root = filepath.Join(filepath.Dir(configPath), cfg.ProjectRoot)
+		if filepath.IsAbs(cfg.ProjectRoot) {
+			root = cfg.ProjectRoot
+		}
+	}
+	cfg.ResolvePaths(root)
+	if opts.Jobs > 0 {
+		cfg.Jobs = opts.Jobs
+	}
+	if opts.Out != "" {
+		return opts.Out
+	}
+	return cfg.OutputPath
+}
+
 func printHelp() {
 	help := `GoSitemap - SvelteKit static sitemap generator
 
 Usage:
   go run . [options]
   ./gositemap [options]
+  ./gositemap validate [options] [file]
+  ./gositemap check [--build-dir DIR] [options]
+  ./gositemap watch [--interval 500ms] [options]
+
+Commands:
+  validate [file]  Check a sitemap or sitemap index against the sitemaps.org
+                   protocol (default: the configured output path). Exits with
+                   status 1 when problems are found.
+  check            Compare the URLs the sitemap would contain with the static
+                   build: reports URLs without a prerendered file and pages
+                   missing from the sitemap. Use --build-dir to set the build.
+  watch            Generate the sitemap, then regenerate it whenever a route,
+                   a content file or gositemap.toml changes, printing the URLs
+                   added and removed. Files are polled every --interval.
 
 Options:
-  --help, -h     Show this help message and exit
-  --dry-run      Print sitemap.xml to stdout instead of writing to file
-  --quiet        Suppress all output except errors
+  --help, -h           Show this help message and exit
+  --dry-run            Print sitemap.xml to stdout instead of writing to file
+  --quiet              Suppress all output except errors
+  --gzip               Also write a gzip-compressed sitemap.xml.gz
+  --config PATH        Path to gositemap.toml (default: <root>/gositemap.toml)
+  --root DIR           SvelteKit project root (overrides project_root)
+  --out PATH           Sitemap output path (overrides output_path)
+  --jobs N             Number of directories scanned at once (overrides jobs,
+                       default: number of CPUs)
+  --build-dir DIR      Build directory for check (overrides build_dir,
+                       default: build)
+  --interval DURATION  Polling interval of watch (default: 500ms)
 
 If gositemap.toml does not exist, it will be generated interactively.
 
 Example gositemap.toml:
 
 base_url = "https://yoursite.com"
+# project_root = "apps/web"          # relative to this file
+# routes_dir = "src/routes"          # default
+# output_path = "static/sitemap.xml" # default
+# sitemap_url = "https://yoursite.com/sitemap.xml"  # default: from output_path
+lastmod_source = "git"               # "mtime" (default), "git" or "hash"
+prune = true                         # drop entries of deleted pages
+keep = ["/legacy"]                   # ...except these
+gzip = true                          # also write sitemap.xml.gz
+# gzip_only = true                   # write only sitemap.xml.gz
+# jobs = 4                           # parallel scans (default: CPUs)
 
 [content_types]
 blog = "src/lib/content"
@@ -59,6 +161,13 @@ exclude = [
   "/admin",
   "/secret"
 ]
+
+[[dynamic]]
+pattern = "/products/[id]"
+values = ["shoe", "hat"]
+
+[robots]                             # update static/robots.txt
+disallow_excluded = true             # add Disallow lines for exclude
 `
 	fmt.Println(help)
 }
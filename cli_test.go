@@ -1,19 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func buildBinary(t *testing.T, tmpdir string) string {
 	binRoot := "gositemap-test-bin"
-	projectRoot := "/home/loops/1Dev/Projects/gositemap"
-	cmd := exec.Command("go", "build", "-o", binRoot, "main.go", "cli.go")
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	cmd := exec.Command("go", "build", "-o", binRoot, ".")
 	cmd.Dir = projectRoot
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -97,3 +104,132 @@ func TestInvalidBaseURL(t *testing.T) {
 		t.Errorf("missing error for invalid base_url: %s", out)
 	}
 }
+
+func TestValidateCommand(t *testing.T) {
+	tmp := t.TempDir()
+	bin := buildBinary(t, tmp)
+	os.MkdirAll(filepath.Join(tmp, "static"), 0755)
+	os.WriteFile(filepath.Join(tmp, "gositemap.toml"), []byte("base_url = \"https://mysite.com\"\n"), 0644)
+	valid := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://mysite.com/</loc><lastmod>2024-01-01</lastmod></url>
+</urlset>`
+	os.WriteFile(filepath.Join(tmp, "static", "sitemap.xml"), []byte(valid), 0644)
+	os.WriteFile(filepath.Join(tmp, "bad.xml"), []byte(strings.Replace(valid, "2024-01-01", "01/01/2024", 1)), 0644)
+
+	cmd := exec.Command(bin, "validate")
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(out), "is valid") {
+		t.Errorf("validate should pass on the configured sitemap, got %v: %s", err, out)
+	}
+
+	cmd = exec.Command(bin, "validate", "bad.xml")
+	cmd.Dir = tmp
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("validate should exit non-zero on an invalid sitemap: %s", out)
+	}
+	if !strings.Contains(string(out), "invalid lastmod") {
+		t.Errorf("missing lastmod problem: %s", out)
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	tmp := t.TempDir()
+	bin := buildBinary(t, tmp)
+	os.MkdirAll(filepath.Join(tmp, "src", "routes", "about"), 0755)
+	os.WriteFile(filepath.Join(tmp, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmp, "src", "routes", "about", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmp, "gositemap.toml"), []byte("base_url = \"https://mysite.com\"\n"), 0644)
+	os.MkdirAll(filepath.Join(tmp, "dist"), 0755)
+	os.WriteFile(filepath.Join(tmp, "dist", "index.html"), []byte(""), 0644)
+
+	cmd := exec.Command(bin, "check", "--quiet", "--build-dir", "dist")
+	cmd.Dir = tmp
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("check should fail when /about is not prerendered: %s", out)
+	}
+	if !strings.Contains(string(out), "would 404): https://mysite.com/about") {
+		t.Errorf("missing /about in check output: %s", out)
+	}
+
+	os.WriteFile(filepath.Join(tmp, "dist", "about.html"), []byte(""), 0644)
+	cmd = exec.Command(bin, "check", "--build-dir", "dist")
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("check should pass, got %v: %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "static", "sitemap.xml")); err == nil {
+		t.Error("check should not write the sitemap")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while the watcher writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchCommand(t *testing.T) {
+	tmp := t.TempDir()
+	os.MkdirAll(filepath.Join(tmp, "src", "routes", "about"), 0755)
+	os.WriteFile(filepath.Join(tmp, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmp, "src", "routes", "about", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(tmp, "static"), 0755)
+	configPath := filepath.Join(tmp, "gositemap.toml")
+	os.WriteFile(configPath, []byte("base_url = \"https://mysite.com\"\nprune = true\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout, stderr syncBuffer
+	done := make(chan error)
+	go func() {
+		opts := CLIOptions{Command: "watch", Root: tmp, Interval: 10 * time.Millisecond}
+		done <- runWatch(ctx, &stdout, &stderr, opts, configPath)
+	}()
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(stdout.String(), want) {
+			select {
+			case err := <-done:
+				t.Fatalf("watch stopped early: %v", err)
+			case <-time.After(10 * time.Millisecond):
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %q, got: %s%s", want, stdout.String(), stderr.String())
+			}
+		}
+	}
+	waitFor("Watching for changes")
+
+	os.MkdirAll(filepath.Join(tmp, "src", "routes", "contact"), 0755)
+	os.WriteFile(filepath.Join(tmp, "src", "routes", "contact", "+page.svelte"), []byte(""), 0644)
+	waitFor("+ https://mysite.com/contact")
+
+	os.RemoveAll(filepath.Join(tmp, "src", "routes", "about"))
+	waitFor("- https://mysite.com/about")
+
+	data, _ := os.ReadFile(filepath.Join(tmp, "static", "sitemap.xml"))
+	if !strings.Contains(string(data), "https://mysite.com/contact") || strings.Contains(string(data), "https://mysite.com/about") {
+		t.Errorf("Expected the sitemap to be rewritten: %s", data)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch should stop cleanly, got %v", err)
+	}
+}
@@ -2,4 +2,7 @@ module gositemap
 
 go 1.21
 
-require github.com/pelletier/go-toml/v2 v2.0.9
+require (
+	github.com/pelletier/go-toml/v2 v2.0.9
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gositemap/sitemap"
 	"io"
-	"net/url"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 )
@@ -20,174 +23,321 @@ const (
 
 func runApp(stdout, stderr io.Writer, args []string) error {
 	opts := ParseCLI(args)
+	switch opts.Command {
+	case "validate":
+		return runValidate(stdout, stderr, opts)
+	}
 
-	routesDir := "src/routes"
-	outputPath := "static/sitemap.xml"
+	configPath := opts.configPath()
 
-	if _, err := os.Stat("gositemap.toml"); os.IsNotExist(err) {
-		fmt.Fprintf(stdout, Yellow+"Config file 'gositemap.toml' not found. Please enter your website base URL (e.g. https://mysite.com): "+Reset)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		fmt.Fprintf(stdout, Yellow+"Config file '%s' not found. Please enter your website base URL (e.g. https://mysite.com): "+Reset, configPath)
 		var url string
 		fmt.Fscanln(os.Stdin, &url)
-		f, ferr := os.Create("gositemap.toml")
+		f, ferr := os.Create(configPath)
 		if ferr != nil {
-			return fmt.Errorf(Red+"Could not create gositemap.toml: %w"+Reset, ferr)
+			return fmt.Errorf(Red+"Could not create %s: %w"+Reset, configPath, ferr)
 		}
 		f.WriteString("base_url = \"" + url + "\"\n\n# You can exclude routes from the sitemap here.\nexclude = [\n  \"/admin\",\n]\n\n# You can define content types that have frontmatter here.\n[content_types]\nblog = \"src/lib/content\"\n")
 		f.Close()
-		fmt.Fprintf(stdout, Green+"Created gositemap.toml with your base URL."+Reset+"\n")
+		fmt.Fprintf(stdout, Green+"Created %s with your base URL."+Reset+"\n", configPath)
 	}
 
-	cfg, err := sitemap.LoadConfig("gositemap.toml")
-	if err != nil {
-		return fmt.Errorf(Red+"Could not load gositemap.toml: %w"+Reset, err)
+	if opts.Command == "watch" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return runWatch(ctx, stdout, stderr, opts, configPath)
 	}
 
-	base := "http://localhost"
-	if cfg != nil && cfg.BaseURL != "" {
-		base = cfg.BaseURL
+	s, err := loadSite(stderr, opts, configPath)
+	if err != nil {
+		return err
 	}
-	// Validate base_url
-	parsed, err := url.Parse(base)
-	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
-		return fmt.Errorf(Red + "Invalid base_url in config: must be a valid URL (e.g. https://mysite.com)" + Reset)
+	result, err := s.gen.Generate(context.Background())
+	if err != nil {
+		return s.generateError(err)
 	}
-	base = strings.TrimRight(base, "/")
+	return s.write(stdout, stderr, opts, result)
+}
 
-	contentTypes := map[string]string{"blog": "src/lib/content"}
-	if cfg != nil && len(cfg.ContentTypes) > 0 {
-		contentTypes = cfg.ContentTypes
-	}
-	var allContent []sitemap.ContentMeta
-	for slug, dir := range contentTypes {
-		freq := "never"
-		if cfg != nil && cfg.ChangeFreq != nil && cfg.ChangeFreq[slug] != "" {
-			freq = cfg.ChangeFreq[slug]
-		}
-		metas, err := sitemap.ScanContent(dir, slug, freq)
-		if err != nil {
-			fmt.Fprintf(stderr, "Error scanning content in %s: %v\n", dir, err)
-			continue
-		}
-		allContent = append(allContent, metas...)
-	}
+// site is a loaded config and the generator built from it.
+type site struct {
+	cfg          *sitemap.Config
+	gen          *sitemap.Generator
+	outputPath   string
+	existingPath string
+}
 
-	if cfg != nil {
-		for _, glob := range cfg.Glob {
-			for _, pattern := range glob.Paths {
-				dirs, err := filepath.Glob(pattern)
-				if err != nil {
-					fmt.Fprintf(stderr, "Error matching glob pattern '%s': %v\n", pattern, err)
-					continue
-				}
-				for _, dir := range dirs {
-					addContent(dir, &allContent, cfg)
-				}
-			}
-		}
+// loadSite reads the config and creates the generator, with the entries of
+// the existing sitemap.
+func loadSite(stderr io.Writer, opts CLIOptions, configPath string) (*site, error) {
+	cfg, err := sitemap.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf(Red+"Could not load %s: %w"+Reset, configPath, err)
 	}
 
-	excludeList := []string{}
-	if cfg != nil && cfg.Exclude != nil {
-		excludeList = cfg.Exclude
-	}
+	outputPath := opts.resolvePaths(cfg, configPath)
 
-	// Pass excludeList to ScanRoutes
-	routes, err := sitemap.ScanRoutes(routesDir, excludeList)
-	if err != nil {
-		fmt.Fprintf(stderr, "Error scanning routes in %s: %v\n", routesDir, err)
-		return err // Or handle as appropriate
+	gen, err := sitemap.NewGenerator(cfg)
+	var configErr *sitemap.ConfigError
+	if errors.As(err, &configErr) {
+		return nil, fmt.Errorf(Red+"Invalid %s in config: %w"+Reset, configErr.Key, configErr.Err)
+	} else if err != nil {
+		return nil, fmt.Errorf(Red+"%v"+Reset, err)
 	}
 
-	var existingURLs []sitemap.URL
-	if _, err := os.Stat(outputPath); err == nil {
-		loadedURLs, loadErr := sitemap.LoadSitemap(outputPath)
+	gen.DirURL = cfg.SitemapDirURL(gen.Base, outputPath)
+
+	existingPath := existingSitemapPath(outputPath, cfg.GzipOnly)
+	if _, err := os.Stat(existingPath); err == nil {
+		loadedURLs, loadErr := sitemap.LoadSitemap(existingPath)
 		if loadErr != nil {
 			fmt.Fprintf(stderr, "Error loading existing sitemap: %v\n", loadErr)
 		} else {
-			existingURLs = loadedURLs
+			gen.Existing = loadedURLs
 		}
 	}
+	return &site{cfg: cfg, gen: gen, outputPath: outputPath, existingPath: existingPath}, nil
+}
 
-	all := len(routes) + len(allContent)
-	if all == 0 && len(existingURLs) == 0 {
+// generateError formats an error returned by Generate.
+func (s *site) generateError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) && s.cfg.Source == sitemap.OriginBuild {
+		return fmt.Errorf(Red+"Build directory '%s' not found: build your site before using source = \"build\""+Reset, s.cfg.BuildDir)
+	}
+	return fmt.Errorf(Red+"Error generating sitemap: %w"+Reset, err)
+}
+
+// write reports the result and writes the sitemap files, news sitemap and
+// robots.txt, or prints them with --dry-run. The check command compares the
+// result with the build instead.
+func (s *site) write(stdout, stderr io.Writer, opts CLIOptions, result *sitemap.Result) error {
+	cfg, gen, outputPath, existingPath := s.cfg, s.gen, s.outputPath, s.existingPath
+	base := gen.Base
+	gzipOnly := cfg.GzipOnly
+	gzipOutput := cfg.Gzip || gzipOnly || opts.Gzip
+
+	for _, w := range result.Warnings {
+		fmt.Fprintf(stderr, Yellow+"Warning: %v"+Reset+"\n", w)
+	}
+
+	all := len(result.Found)
+	if all == 0 && len(gen.Existing) == 0 {
 		if !opts.Quiet {
 			fmt.Fprintf(stdout, Yellow+"No page or article found, nothing to do."+Reset+"\n")
 		}
 		return nil
 	}
 
-	for _, r := range routes {
-		if !opts.Quiet {
-			msg := fmt.Sprintf(Blue+"Detected page: %s (lastmod: %s", r.URL, r.LastMod)
-			if r.ChangeFreq != "" {
-				msg += ", changefreq: " + r.ChangeFreq
-			}
-			msg += ")" + Reset
-			fmt.Fprintf(stdout, msg+"\n")
+	for _, e := range result.Found {
+		if opts.Quiet {
+			continue
+		}
+		if e.IsArticle() {
+			fmt.Fprintf(stdout, Blue+"Detected article: %s (lastmod: %s, changefreq: %s)"+Reset+"", e.Loc, e.LastModString(), e.ChangeFreq)
+			continue
 		}
+		msg := fmt.Sprintf(Blue+"Detected page: %s (lastmod: %s", e.Loc, e.LastModString())
+		if e.ChangeFreq != "" {
+			msg += ", changefreq: " + e.ChangeFreq
+		}
+		msg += ")" + Reset
+		fmt.Fprintf(stdout, msg+"\n")
 	}
-	for _, meta := range allContent {
+	for _, u := range result.Removed {
 		if !opts.Quiet {
-			fmt.Fprintf(stdout, Blue+"Detected article: %s (lastmod: %s, changefreq: %s)"+Reset+"", meta.URL, meta.LastMod, meta.ChangeFreq)
+			fmt.Fprintf(stdout, Yellow+"Removed page no longer found: %s"+Reset+"\n", u.Loc)
 		}
 	}
 
-	// Determine if we should overwrite existing sitemap entries
-	overwriteExisting := false // Default to false (add only, preserve existing lastmod)
-	if cfg.PreserveExisting != nil && !*cfg.PreserveExisting { // If preserve_existing is explicitly false
-		overwriteExisting = true // Then we overwrite existing entries
+	urls := result.Entries
+	if opts.Command == "check" {
+		return runCheck(stdout, opts, cfg, base, urls)
+	}
+	names := []string{filepath.Base(outputPath)}
+	if gzipOnly {
+		names = []string{names[0] + sitemap.GzipExt}
+	} else if gzipOutput {
+		names = append(names, names[0]+sitemap.GzipExt)
+	}
+	var files, primary []sitemap.SitemapFile
+	var written []string
+	childFiles := 0
+	for _, name := range names {
+		built, err := gen.Files(name)
+		if err != nil {
+			return fmt.Errorf(Red+"Error building sitemap: %w"+Reset, err)
+		}
+		if primary == nil {
+			primary = built
+		}
+		files = append(files, built...)
+		written = append(written, filepath.Join(filepath.Dir(outputPath), name))
+		childFiles = len(built) - 1
+	}
+	var newsPath string
+	if cfg.News != nil {
+		newsPath = cfg.News.OutputPath
+		if newsPath == "" {
+			newsPath = filepath.Join(filepath.Dir(outputPath), "news-sitemap.xml")
+		}
+	}
+	var robotsTxt, robotsPath string
+	if cfg.Robots != nil {
+		robotsPath = cfg.RobotsPath(outputPath)
+		listed := primary[:1]
+		if cfg.Robots.ListChildren {
+			listed = primary
+		}
+		var locs []string
+		for _, f := range listed {
+			locs = append(locs, gen.DirURL+f.Name)
+		}
+		if newsPath != "" {
+			locs = append(locs, cfg.PublicURL(base, newsPath))
+		}
+		var disallow []string
+		if cfg.Robots.DisallowExcluded {
+			disallow = sitemap.RobotsDisallow(cfg.Exclude)
+		}
+		existing, err := os.ReadFile(robotsPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf(Red+"Error reading %s: %w"+Reset, robotsPath, err)
+		}
+		robotsTxt = sitemap.UpdateRobots(string(existing), locs, disallow)
 	}
 
-	// Pass changefreq config to sitemap.GenerateSitemap if needed
-	xml := sitemap.GenerateSitemap(base, routes, allContent, existingURLs, overwriteExisting) // Pass new arg
 	if opts.DryRun {
 		if !opts.Quiet {
 			fmt.Fprintf(stdout, Green+"--- DRY RUN: sitemap.xml output ---\n"+Reset)
 		}
-		if !overwriteExisting { // If we are in "add only" mode
-			if _, err := os.Stat(outputPath); err == nil {
+		if cfg.PreserveExisting == nil || *cfg.PreserveExisting { // If we are in "add only" mode
+			if _, err := os.Stat(existingPath); err == nil {
 				if !opts.Quiet {
-					fmt.Fprintf(stdout, Yellow+"Sitemap file already exists at %s. In dry run, new entries would be added, existing entries would be preserved.\n"+Reset, outputPath)
-                }
-                fmt.Fprintf(stdout, xml+"\n") // Still print the XML in dry run, but with the correct message
-                return nil // This line is restored
-            }
-        }
-        // If overwriteExisting is true, or no existing sitemap, just print the XML
-        fmt.Fprintf(stdout, xml+"\n")
-        return nil
+					fmt.Fprintf(stdout, Yellow+"Sitemap file already exists at %s. In dry run, new entries would be added, existing entries would be preserved.\n"+Reset, existingPath)
+				}
+			}
+		}
+		for _, f := range files {
+			if len(files) > 1 && !opts.Quiet {
+				fmt.Fprintf(stdout, Green+"--- %s ---\n"+Reset, f.Name)
+			}
+			if _, err := f.WriteTo(stdout); err != nil {
+				return fmt.Errorf(Red+"Error writing sitemap: %w"+Reset, err)
+			}
+			fmt.Fprintln(stdout)
+		}
+		if newsPath != "" {
+			if !opts.Quiet {
+				fmt.Fprintf(stdout, Green+"--- %s ---\n"+Reset, filepath.Base(newsPath))
+			}
+			if _, err := gen.WriteNewsTo(stdout); err != nil {
+				return fmt.Errorf(Red+"Error writing news sitemap: %w"+Reset, err)
+			}
+			fmt.Fprintln(stdout)
+		}
+		if robotsTxt != "" {
+			if !opts.Quiet {
+				fmt.Fprintf(stdout, Green+"--- %s ---\n"+Reset, filepath.Base(robotsPath))
+			}
+			fmt.Fprint(stdout, robotsTxt)
+		}
+		return nil
 	}
 
-	// No longer need the `if shouldSkip { ... return nil }` block here
-	// The logic for preserving/overwriting is now inside GenerateSitemap
-
-	if err := os.WriteFile(outputPath, []byte(xml), 0644); err != nil {
+	previous, err := previousSitemaps(outputPath, names, gzipOnly, gen.DirURL)
+	if err != nil {
+		return fmt.Errorf(Red+"Error reading previous sitemap: %w"+Reset, err)
+	}
+	if err := sitemap.WriteSitemapFiles(filepath.Dir(outputPath), files); err != nil {
 		return fmt.Errorf(Red+"Error writing sitemap: %w"+Reset, err)
 	}
+	removed, err := sitemap.RemoveStaleSitemaps(filepath.Dir(outputPath), previous, files)
+	if err != nil {
+		return fmt.Errorf(Red+"Error removing stale sitemap: %w"+Reset, err)
+	}
 	if !opts.Quiet {
-		fmt.Fprintf(stdout, Green+"Sitemap successfully generated (%d entries) in %s"+Reset+"\n", all, outputPath)
+		for _, name := range removed {
+			fmt.Fprintf(stdout, Yellow+"Removed stale sitemap %s"+Reset+"\n", filepath.Join(filepath.Dir(outputPath), name))
+		}
+	}
+	if gen.State != nil {
+		if err := gen.State.Save(cfg.StateFile); err != nil {
+			return fmt.Errorf(Red+"Error writing %s: %w"+Reset, cfg.StateFile, err)
+		}
+	}
+	if !opts.Quiet {
+		if childFiles > 0 {
+			fmt.Fprintf(stdout, Green+"Sitemap index successfully generated (%d entries in %d files) in %s"+Reset+"\n", all, childFiles, strings.Join(written, ", "))
+		} else {
+			fmt.Fprintf(stdout, Green+"Sitemap successfully generated (%d entries) in %s"+Reset+"\n", all, strings.Join(written, ", "))
+		}
+	}
+	if newsPath != "" {
+		if err := writeNews(gen, newsPath); err != nil {
+			return fmt.Errorf(Red+"Error writing news sitemap: %w"+Reset, err)
+		}
+		if !opts.Quiet {
+			fmt.Fprintf(stdout, Green+"News sitemap successfully generated in %s"+Reset+"\n", newsPath)
+		}
+	}
+	if robotsTxt != "" {
+		if err := os.WriteFile(robotsPath, []byte(robotsTxt), 0644); err != nil {
+			return fmt.Errorf(Red+"Error writing robots.txt: %w"+Reset, err)
+		}
+		if !opts.Quiet {
+			fmt.Fprintf(stdout, Green+"robots.txt successfully updated in %s"+Reset+"\n", robotsPath)
+		}
 	}
 	return nil
 }
 
-func addContent(dir string, allContent *[]sitemap.ContentMeta, cfg *sitemap.Config) {
-	fi, err := os.Stat(dir)
-	if err != nil || !fi.IsDir() {
-		return
+// previousSitemaps returns the files written by an earlier run that this run
+// may leave behind: the children listed by the sitemap indexes about to be
+// overwritten and, under gzipOnly, the plain sitemap and its children.
+func previousSitemaps(outputPath string, names []string, gzipOnly bool, dirURL string) ([]string, error) {
+	var previous []string
+	if gzipOnly {
+		plain := filepath.Base(outputPath)
+		names = append(names[:len(names):len(names)], plain)
+		previous = append(previous, plain)
 	}
-
-	slug := filepath.Base(dir)
-	freq := "never"
-	if cfg != nil && cfg.ChangeFreq != nil {
-		if f, ok := cfg.ChangeFreq[dir]; ok {
-			freq = f
-		} else if f, ok := cfg.ChangeFreq[slug]; ok {
-			freq = f
+	for _, name := range names {
+		children, err := sitemap.SitemapChildren(filepath.Join(filepath.Dir(outputPath), name), dirURL)
+		if err != nil {
+			return nil, err
 		}
+		previous = append(previous, children...)
+	}
+	return previous, nil
+}
+
+// writeNews streams the news sitemap of gen to path.
+func writeNews(gen *sitemap.Generator, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
-	if metas, err := sitemap.ScanContent(dir, slug, freq); err == nil {
-		*allContent = append(*allContent, metas...)
+	if _, err := gen.WriteNewsTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// existingSitemapPath returns the sitemap to preserve entries from: the plain
+// output file, or its .gz version when only that one exists or gzipOnly is set.
+func existingSitemapPath(outputPath string, gzipOnly bool) string {
+	gzPath := outputPath + sitemap.GzipExt
+	if _, err := os.Stat(gzPath); err == nil {
+		if gzipOnly {
+			return gzPath
+		}
+		if _, err := os.Stat(outputPath); err != nil {
+			return gzPath
+		}
 	}
+	return outputPath
 }
 
 func main() {
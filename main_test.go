@@ -62,7 +62,7 @@ func TestRunAppSkipExisting(t *testing.T) {
 
 	// Build the gositemap binary
 	binPath := filepath.Join(tempDir, "gositemap-test-bin")
-	cmd := exec.Command("go", "build", "-o", binPath, "main.go", "cli.go")
+	cmd := exec.Command("go", "build", "-o", binPath, ".")
 	cmd.Dir = originalWd // Build from the project root
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -225,3 +225,134 @@ output_path = "static/sitemap.xml"
 			}
 		})
 	}
+
+func TestRunAppProjectRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	bin := buildBinary(t, tempDir)
+
+	app := filepath.Join(tempDir, "apps", "web")
+	os.MkdirAll(filepath.Join(app, "src", "pages", "about"), 0755)
+	os.WriteFile(filepath.Join(app, "src", "pages", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(app, "src", "pages", "about", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(app, "content"), 0755)
+	os.WriteFile(filepath.Join(app, "content", "hello.md"), []byte("---\ndate: 2024-01-01\n---\n"), 0644)
+	os.MkdirAll(filepath.Join(app, "public"), 0755)
+	config := `base_url = "https://example.com"
+routes_dir = "src/pages"
+output_path = "public/sitemap.xml"
+
+[content_types]
+blog = "content"
+`
+	os.WriteFile(filepath.Join(app, "gositemap.toml"), []byte(config), 0644)
+
+	t.Run("--root", func(t *testing.T) {
+		cmd := exec.Command(bin, "--root", "apps/web")
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Command returned an error: %v\n%s", err, out)
+		}
+		content, err := os.ReadFile(filepath.Join(app, "public", "sitemap.xml"))
+		if err != nil {
+			t.Fatalf("Expected sitemap in output_path: %v", err)
+		}
+		for _, loc := range []string{"https://example.com/", "https://example.com/about", "https://example.com/blog/hello"} {
+			if !strings.Contains(string(content), "<loc>"+loc+"</loc>") {
+				t.Errorf("Expected %s in sitemap. Got: %s", loc, content)
+			}
+		}
+	})
+
+	t.Run("--config with project_root and --out", func(t *testing.T) {
+		os.WriteFile(filepath.Join(tempDir, "web.toml"), []byte("project_root = \"apps/web\"\n"+config), 0644)
+		other := t.TempDir()
+		out := filepath.Join(other, "sitemap.xml")
+		cmd := exec.Command(bin, "--config", filepath.Join(tempDir, "web.toml"), "--out", out)
+		cmd.Dir = other
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Command returned an error: %v\n%s", err, output)
+		}
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected sitemap at --out path: %v", err)
+		}
+		if !strings.Contains(string(content), "<loc>https://example.com/about</loc>") {
+			t.Errorf("Expected routes from project_root. Got: %s", content)
+		}
+	})
+}
+
+func TestRunAppRobots(t *testing.T) {
+	tempDir := t.TempDir()
+	bin := buildBinary(t, tempDir)
+	os.MkdirAll(filepath.Join(tempDir, "src", "routes"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "static"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "static", "robots.txt"), []byte("User-agent: *\nDisallow: /private\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "gositemap.toml"), []byte("base_url = \"https://example.com\"\nexclude = [\"/admin\"]\n\n[robots]\ndisallow_excluded = true\n"), 0644)
+
+	cmd := exec.Command(bin)
+	cmd.Dir = tempDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Command returned an error: %v\n%s", err, out)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "static", "robots.txt"))
+	if err != nil {
+		t.Fatalf("Expected robots.txt: %v", err)
+	}
+	for _, line := range []string{"Disallow: /private", "Disallow: /admin", "Sitemap: https://example.com/sitemap.xml"} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("Expected %q in robots.txt. Got: %s", line, content)
+		}
+	}
+}
+
+func TestRunAppSitemapSubdir(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "src", "routes"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "static", "maps"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "gositemap.toml"), []byte("base_url = \"https://example.com\"\noutput_path = \"static/maps/sitemap.xml\"\n\n[robots]\n"), 0644)
+
+	var stdout, stderr strings.Builder
+	if err := runApp(&stdout, &stderr, []string{"--quiet", "--root", tempDir}); err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "static", "robots.txt"))
+	if err != nil {
+		t.Fatalf("Expected robots.txt at the static root: %v", err)
+	}
+	if !strings.Contains(string(content), "Sitemap: https://example.com/maps/sitemap.xml\n") {
+		t.Errorf("Expected the sitemap URL to include its directory. Got: %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "static", "maps", "robots.txt")); err == nil {
+		t.Error("robots.txt should not be written next to the sitemap")
+	}
+}
+
+func TestRunAppGzipOnlyRemovesPlain(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "src", "routes"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "static"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "static", "sitemap-2023.xml"), []byte(""), 0644)
+	config := filepath.Join(tempDir, "gositemap.toml")
+	os.WriteFile(config, []byte("base_url = \"https://example.com\"\n"), 0644)
+
+	var stdout, stderr strings.Builder
+	if err := runApp(&stdout, &stderr, []string{"--quiet", "--root", tempDir}); err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	os.WriteFile(config, []byte("base_url = \"https://example.com\"\ngzip_only = true\n"), 0644)
+	if err := runApp(&stdout, &stderr, []string{"--quiet", "--root", tempDir}); err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "static", "sitemap.xml")); err == nil {
+		t.Error("Expected the plain sitemap to be removed under gzip_only")
+	}
+	for _, name := range []string{"sitemap.xml.gz", "sitemap-2023.xml"} {
+		if _, err := os.Stat(filepath.Join(tempDir, "static", name)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", name, err)
+		}
+	}
+}
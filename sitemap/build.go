@@ -0,0 +1,117 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildOptions controls how ScanBuild reads a static build.
+type BuildOptions struct {
+	Exclude []string
+	// PrerenderedDir is SvelteKit's .svelte-kit/output/prerendered directory.
+	// When its pages directory exists, it is used as the list of pages instead
+	// of the build directory, which may also hold copied static files.
+	PrerenderedDir string
+	// TrailingSlash mirrors SvelteKit's trailingSlash option: "never" (the
+	// default), "always" or "ignore".
+	TrailingSlash string
+}
+
+// ScanBuild returns an Entry for every page prerendered in buildDir, such as
+// the output of @sveltejs/adapter-static.
+func ScanBuild(buildDir string, opts BuildOptions) ([]Entry, error) {
+	return scanBuild(context.Background(), buildDir, opts)
+}
+
+// scanBuild is ScanBuild, stopping when ctx is done.
+func scanBuild(ctx context.Context, buildDir string, opts BuildOptions) ([]Entry, error) {
+	switch opts.TrailingSlash {
+	case "", "never", "always", "ignore":
+	default:
+		return nil, fmt.Errorf("invalid trailing_slash %q: must be never, always or ignore", opts.TrailingSlash)
+	}
+
+	root := buildDir
+	if opts.PrerenderedDir != "" {
+		pages := filepath.Join(opts.PrerenderedDir, "pages")
+		if fi, err := os.Stat(pages); err == nil && fi.IsDir() {
+			root = pages
+		}
+	}
+	if fi, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	var metas []Entry
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// SvelteKit's immutable assets
+			if rel == "_app" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(rel, ".html") || rel == "404.html" || rel == "200.html" {
+			return nil
+		}
+
+		url := buildFileURL(rel, opts.TrailingSlash)
+		if isExcluded(url, opts.Exclude) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		metas = append(metas, Entry{
+			Loc:        url,
+			LastMod:    dateOf(fi.ModTime()),
+			ChangeFreq: defaultRouteChangeFreq(strings.TrimSuffix(url, "/")),
+			Images:     imageRefsOf(fileImages(path)),
+			Sources:    []string{path},
+			Origin:     OriginBuild,
+		})
+		return nil
+	})
+	return metas, err
+}
+
+// buildFileURL maps a prerendered file to its URL: "about.html" and
+// "about/index.html" are "/about" or "/about/" depending on trailingSlash.
+func buildFileURL(rel, trailingSlash string) string {
+	url := "/" + strings.TrimSuffix(rel, ".html")
+	slash := false
+	if url == "/index" {
+		return "/"
+	} else if strings.HasSuffix(url, "/index") {
+		url = strings.TrimSuffix(url, "/index")
+		slash = true
+	}
+
+	switch trailingSlash {
+	case "always":
+		slash = true
+	case "ignore":
+	default:
+		slash = false
+	}
+	if slash {
+		url += "/"
+	}
+	return url
+}
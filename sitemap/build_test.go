@@ -0,0 +1,104 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func writeBuild(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("<html></html>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func buildURLs(metas []sitemap.Entry) []string {
+	var urls []string
+	for _, m := range metas {
+		urls = append(urls, m.Loc)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestScanBuild(t *testing.T) {
+	t.Run("maps html files to urls", func(t *testing.T) {
+		build := t.TempDir()
+		writeBuild(t, build, "index.html", "about.html", "blog/index.html", "blog/hello.html",
+			"products/1.html", "404.html", "_app/immutable/x.html", "admin.html", "favicon.png")
+
+		metas, err := sitemap.ScanBuild(build, sitemap.BuildOptions{Exclude: []string{"/admin"}})
+		if err != nil {
+			t.Fatalf("ScanBuild failed: %v", err)
+		}
+		got := buildURLs(metas)
+		want := []string{"/", "/about", "/blog", "/blog/hello", "/products/1"}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %d pages, got %v", len(want), got)
+		}
+		for i, w := range want {
+			if got[i] != w {
+				t.Errorf("Expected %s, got %s", w, got[i])
+			}
+		}
+	})
+
+	t.Run("honours trailing slash", func(t *testing.T) {
+		build := t.TempDir()
+		writeBuild(t, build, "index.html", "about.html", "blog/index.html")
+
+		cases := map[string][]string{
+			"always": {"/", "/about/", "/blog/"},
+			"ignore": {"/", "/about", "/blog/"},
+			"never":  {"/", "/about", "/blog"},
+		}
+		for mode, want := range cases {
+			metas, err := sitemap.ScanBuild(build, sitemap.BuildOptions{TrailingSlash: mode})
+			if err != nil {
+				t.Fatalf("ScanBuild(%s) failed: %v", mode, err)
+			}
+			got := buildURLs(metas)
+			for i, w := range want {
+				if got[i] != w {
+					t.Errorf("trailing_slash=%s: expected %s, got %s", mode, w, got[i])
+				}
+			}
+		}
+
+		if _, err := sitemap.ScanBuild(build, sitemap.BuildOptions{TrailingSlash: "sometimes"}); err == nil {
+			t.Error("Expected an error for an invalid trailing_slash")
+		}
+	})
+
+	t.Run("prefers prerendered pages", func(t *testing.T) {
+		tmp := t.TempDir()
+		build := filepath.Join(tmp, "build")
+		writeBuild(t, build, "index.html", "google1234.html")
+		prerendered := filepath.Join(tmp, ".svelte-kit", "output", "prerendered")
+		writeBuild(t, filepath.Join(prerendered, "pages"), "index.html", "products/42.html")
+
+		metas, err := sitemap.ScanBuild(build, sitemap.BuildOptions{PrerenderedDir: prerendered})
+		if err != nil {
+			t.Fatalf("ScanBuild failed: %v", err)
+		}
+		got := buildURLs(metas)
+		if len(got) != 2 || got[0] != "/" || got[1] != "/products/42" {
+			t.Errorf("Expected pages from prerendered output, got %v", got)
+		}
+	})
+
+	t.Run("missing build directory", func(t *testing.T) {
+		_, err := sitemap.ScanBuild(filepath.Join(t.TempDir(), "build"), sitemap.BuildOptions{})
+		if !os.IsNotExist(err) {
+			t.Errorf("Expected a not-exist error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package sitemap
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CheckResult lists the differences between a sitemap and a static build.
+type CheckResult struct {
+	// Missing are sitemap locations with no prerendered file: they would 404.
+	Missing []string
+	// Unlisted are prerendered pages that are not in the sitemap.
+	Unlisted []string
+}
+
+// OK reports whether the sitemap and the build match.
+func (r CheckResult) OK() bool {
+	return len(r.Missing) == 0 && len(r.Unlisted) == 0
+}
+
+// CheckBuild compares the sitemap URLs with the pages prerendered in buildDir.
+// A URL such as /about matches build/about.html or build/about/index.html.
+// URLs on other hosts than base are not checked, and pages excluded by
+// opts.Exclude are not reported as unlisted.
+func CheckBuild(buildDir, base string, urls []Entry, opts BuildOptions) (CheckResult, error) {
+	pages, err := ScanBuild(buildDir, opts)
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	base = strings.TrimRight(base, "/")
+	var result CheckResult
+	listed := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		if u.Loc != base && !strings.HasPrefix(u.Loc, base+"/") {
+			continue
+		}
+		p := strings.TrimPrefix(u.Loc, base)
+		if i := strings.IndexAny(p, "?#"); i >= 0 {
+			p = p[:i]
+		}
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			p = unescaped
+		}
+		listed[trimSlash(p)] = true
+		if !buildFileExists(buildDir, p) {
+			result.Missing = append(result.Missing, u.Loc)
+		}
+	}
+	for _, page := range pages {
+		if !listed[trimSlash(page.Loc)] {
+			result.Unlisted = append(result.Unlisted, page.Loc)
+		}
+	}
+	sort.Strings(result.Missing)
+	sort.Strings(result.Unlisted)
+	return result, nil
+}
+
+// buildFileExists reports whether the page at path p was prerendered.
+func buildFileExists(buildDir, p string) bool {
+	p = strings.Trim(p, "/")
+	candidates := []string{"index.html"}
+	if p != "" {
+		candidates = []string{p + ".html", p + "/index.html", p}
+	}
+	for _, c := range candidates {
+		if fi, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(c))); err == nil && !fi.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func trimSlash(p string) string {
+	if p = strings.TrimRight(p, "/"); p == "" {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestCheckBuild(t *testing.T) {
+	root := t.TempDir()
+	build := filepath.Join(root, "build")
+	writeBuild(t, build, "index.html", "about.html", "blog/index.html", "blog/post.html", "admin/index.html", "hidden.html", "_app/x.html")
+
+	// Routes scanned from a (group) and a content/ folder, as ScanRoutes would.
+	routes := filepath.Join(root, "src", "routes")
+	os.MkdirAll(filepath.Join(routes, "(marketing)", "about"), 0755)
+	os.WriteFile(filepath.Join(routes, "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(routes, "(marketing)", "about", "+page.svelte"), []byte(""), 0644)
+	metas, err := sitemap.ScanRoutes(routes, nil)
+	if err != nil {
+		t.Fatalf("ScanRoutes failed: %v", err)
+	}
+	content := []sitemap.Entry{{Loc: "/blog/post"}, {Loc: "/blog/deleted"}, {Loc: "https://elsewhere.com/x"}}
+	urls := sitemap.MergeEntries("https://example.com", append(metas, content...), nil, false)
+
+	result, err := sitemap.CheckBuild(build, "https://example.com", urls, sitemap.BuildOptions{Exclude: []string{"/admin"}})
+	if err != nil {
+		t.Fatalf("CheckBuild failed: %v", err)
+	}
+	if strings.Join(result.Missing, ",") != "https://example.com/blog/deleted" {
+		t.Errorf("Unexpected missing URLs: %v", result.Missing)
+	}
+	if strings.Join(result.Unlisted, ",") != "/blog,/hidden" {
+		t.Errorf("Unexpected unlisted pages: %v", result.Unlisted)
+	}
+	if result.OK() {
+		t.Error("Expected the check to fail")
+	}
+
+	if _, err := sitemap.CheckBuild(filepath.Join(root, "nope"), "https://example.com", urls, sitemap.BuildOptions{}); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error for a missing build, got %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package sitemap
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -10,14 +14,90 @@ type Glob struct {
 	Paths []string `toml:"paths"`
 }
 
+// DynamicRoute maps a [param] route pattern such as "/products/[id]" to the
+// values its parameters take. Values can be given inline, loaded from a
+// JSON/YAML/CSV file, or taken from the basenames of files matching a glob.
+type DynamicRoute struct {
+	Pattern string `toml:"pattern"`
+	// Param is the parameter plain values are bound to. Defaults to the last
+	// parameter of the pattern.
+	Param  string `toml:"param"`
+	Values []any  `toml:"values"`
+	File   string `toml:"file"`
+	Glob   string `toml:"glob"`
+}
+
+// ContentTypeOptions are the options of one content type, set under
+// [content_options.<type>].
+type ContentTypeOptions struct {
+	// Extensions are the file extensions read as content, e.g. [".md", ".mdx"].
+	Extensions []string `toml:"extensions"`
+	// Recursive walks subdirectories. Defaults to true.
+	Recursive *bool `toml:"recursive"`
+	// Permalink is the URL template of the articles, e.g.
+	// "/blog/{year}/{month}/{slug}".
+	Permalink string `toml:"permalink"`
+}
+
+// Default paths of a SvelteKit project, relative to the project root.
+const (
+	DefaultRoutesDir   = "src/routes"
+	DefaultOutputPath  = "static/sitemap.xml"
+	DefaultContentDir  = "src/lib/content"
+	DefaultBuildDir    = "build"
+	DefaultPrerendered = ".svelte-kit/output/prerendered"
+	// DefaultStaticDir holds the files SvelteKit serves as is from the root.
+	DefaultStaticDir = "static"
+)
+
 type Config struct {
-	BaseURL      string            `toml:"base_url"`
-	OutputPath   string            `toml:"output_path"`
-	PreserveExisting *bool             `toml:"preserve_existing"`
-	ContentTypes map[string]string `toml:"content_types"`
-	ChangeFreq   map[string]string `toml:"changefreq"`
-	Exclude      []string          `toml:"exclude"`
-	Glob         []Glob            `toml:"glob"`
+	BaseURL string `toml:"base_url"`
+	// ProjectRoot is the SvelteKit app directory, relative to the config file.
+	// All other relative paths are relative to it.
+	ProjectRoot      string             `toml:"project_root"`
+	RoutesDir        string             `toml:"routes_dir"`
+	OutputPath       string             `toml:"output_path"`
+	SitemapURL       string             `toml:"sitemap_url"`
+	Source           string             `toml:"source"`
+	BuildDir         string             `toml:"build_dir"`
+	TrailingSlash    string             `toml:"trailing_slash"`
+	Gzip             bool               `toml:"gzip"`
+	GzipOnly         bool               `toml:"gzip_only"`
+	PreserveExisting *bool              `toml:"preserve_existing"`
+	Prune            bool               `toml:"prune"`
+	Keep             []string           `toml:"keep"`
+	ContentTypes     map[string]string  `toml:"content_types"`
+	ChangeFreq       map[string]string  `toml:"changefreq"`
+	Priority         map[string]float64 `toml:"priority"`
+	AutoPriority     bool               `toml:"auto_priority"`
+	LastModKeys      []string           `toml:"lastmod_keys"`
+	LastModSource    string             `toml:"lastmod_source"`
+	StateFile        string             `toml:"state_file"`
+	Jobs             int                `toml:"jobs"`
+	Exclude          []string           `toml:"exclude"`
+	Glob             []Glob             `toml:"glob"`
+	Dynamic          []DynamicRoute     `toml:"dynamic"`
+	I18n             *I18n              `toml:"i18n"`
+	News             *News              `toml:"news"`
+	Crawl            *Crawl             `toml:"crawl"`
+	Robots           *Robots            `toml:"robots"`
+	// TypeOptions are the options of each content type.
+	TypeOptions map[string]ContentTypeOptions `toml:"content_options"`
+}
+
+// ConfigError reports an invalid config value.
+type ConfigError struct {
+	// Key is the config key holding the value.
+	Key string
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s in config: %v", e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -33,3 +113,145 @@ func LoadConfig(path string) (*Config, error) {
 	}
 	return &cfg, nil
 }
+
+// ResolvePaths sets ProjectRoot to root, fills in the default routes, output,
+// build and state paths, and makes every relative path of the config relative
+// to root.
+func (c *Config) ResolvePaths(root string) {
+	c.ProjectRoot = root
+	if c.RoutesDir == "" {
+		c.RoutesDir = DefaultRoutesDir
+	}
+	if c.OutputPath == "" {
+		c.OutputPath = DefaultOutputPath
+	}
+	if c.BuildDir == "" {
+		c.BuildDir = DefaultBuildDir
+	}
+	if c.StateFile == "" {
+		c.StateFile = DefaultStateFile
+	}
+	c.RoutesDir = c.Path(c.RoutesDir)
+	c.OutputPath = c.Path(c.OutputPath)
+	c.BuildDir = c.Path(c.BuildDir)
+	c.StateFile = c.Path(c.StateFile)
+	for name, dir := range c.ContentTypes {
+		c.ContentTypes[name] = c.Path(dir)
+	}
+	for i := range c.Glob {
+		for j, pattern := range c.Glob[i].Paths {
+			c.Glob[i].Paths[j] = c.Path(pattern)
+		}
+	}
+	for i := range c.Dynamic {
+		if c.Dynamic[i].File != "" {
+			c.Dynamic[i].File = c.Path(c.Dynamic[i].File)
+		}
+		if c.Dynamic[i].Glob != "" {
+			c.Dynamic[i].Glob = c.Path(c.Dynamic[i].Glob)
+		}
+	}
+	if c.I18n != nil {
+		for _, dirs := range c.I18n.Content {
+			for locale, dir := range dirs {
+				dirs[locale] = c.Path(dir)
+			}
+		}
+	}
+	if c.News != nil && c.News.OutputPath != "" {
+		c.News.OutputPath = c.Path(c.News.OutputPath)
+	}
+	if c.Robots != nil && c.Robots.OutputPath != "" {
+		c.Robots.OutputPath = c.Path(c.Robots.OutputPath)
+	}
+}
+
+// publicRoot returns the static or build directory holding path, whose files
+// are served from the root of the site. ok is false when path is in neither.
+func (c *Config) publicRoot(path string) (root string, ok bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	for _, dir := range []string{c.Path(DefaultStaticDir), c.BuildDir} {
+		if dir == "" {
+			continue
+		}
+		if d, err := filepath.Abs(dir); err == nil && within(d, abs) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// PublicURL returns the URL a file written at path is served from. Files in
+// the static or build directory keep their path relative to it, other files
+// are served from the root of base.
+func (c *Config) PublicURL(base, path string) string {
+	base = strings.TrimRight(base, "/")
+	if root, ok := c.publicRoot(path); ok {
+		absRoot, _ := filepath.Abs(root)
+		abs, _ := filepath.Abs(path)
+		if rel, err := filepath.Rel(absRoot, abs); err == nil {
+			return base + "/" + filepath.ToSlash(rel)
+		}
+	}
+	return base + "/" + filepath.Base(path)
+}
+
+// SitemapDirURL returns the URL of the directory the sitemap written at
+// outputPath is served from: the directory of sitemap_url, or of its public
+// URL. It ends with a slash.
+func (c *Config) SitemapDirURL(base, outputPath string) string {
+	u := c.SitemapURL
+	if u == "" {
+		u = c.PublicURL(base, outputPath)
+	}
+	return u[:strings.LastIndex(u, "/")+1]
+}
+
+// RobotsPath returns where robots.txt is written: the robots output_path, or
+// the root of the static or build directory holding outputPath, since
+// crawlers only read it from the site root.
+func (c *Config) RobotsPath(outputPath string) string {
+	if c.Robots != nil && c.Robots.OutputPath != "" {
+		return c.Robots.OutputPath
+	}
+	if root, ok := c.publicRoot(outputPath); ok {
+		return filepath.Join(root, "robots.txt")
+	}
+	return filepath.Join(filepath.Dir(outputPath), "robots.txt")
+}
+
+// Path resolves p against ProjectRoot. Absolute paths are returned as is.
+func (c *Config) Path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.ProjectRoot, p)
+}
+
+// Workers returns the number of scans run at once: jobs, or the number of
+// CPUs when it is not set.
+func (c *Config) Workers() int {
+	if c.Jobs > 0 {
+		return c.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// ContentOptions returns the scan options of a content type: its priority,
+// lastmod keys, extensions, permalink and whether to walk subdirectories.
+func (c *Config) ContentOptions(contentType string) ContentOptions {
+	opts := ContentOptions{
+		Type:        contentType,
+		Priority:    c.ContentPriority(contentType),
+		LastModKeys: c.LastModKeys,
+	}
+	if t, ok := c.TypeOptions[contentType]; ok {
+		opts.Extensions = t.Extensions
+		opts.Flat = t.Recursive != nil && !*t.Recursive
+		opts.Permalink = t.Permalink
+	}
+	return opts
+}
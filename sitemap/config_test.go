@@ -2,6 +2,7 @@ package sitemap_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"gositemap/sitemap"
@@ -65,3 +66,74 @@ func TestLoadConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestResolvePaths(t *testing.T) {
+	cfg := &sitemap.Config{
+		ContentTypes: map[string]string{"blog": "content", "docs": "/abs/docs"},
+		Glob:         []sitemap.Glob{{Paths: []string{"src/content/*"}}},
+		Dynamic:      []sitemap.DynamicRoute{{Pattern: "/p/[id]", File: "data/ids.json"}},
+	}
+	cfg.ResolvePaths(filepath.Join("apps", "web"))
+
+	checks := []struct{ got, want string }{
+		{cfg.RoutesDir, filepath.Join("apps", "web", "src", "routes")},
+		{cfg.OutputPath, filepath.Join("apps", "web", "static", "sitemap.xml")},
+		{cfg.BuildDir, filepath.Join("apps", "web", "build")},
+		{cfg.ContentTypes["blog"], filepath.Join("apps", "web", "content")},
+		{cfg.ContentTypes["docs"], "/abs/docs"},
+		{cfg.Glob[0].Paths[0], filepath.Join("apps", "web", "src", "content", "*")},
+		{cfg.Dynamic[0].File, filepath.Join("apps", "web", "data", "ids.json")},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("Expected %s, got %s", c.want, c.got)
+		}
+	}
+}
+
+func TestConfigContentOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gositemap.toml")
+	os.WriteFile(path, []byte(`
+[content_types]
+docs = "src/docs"
+blog = "src/blog"
+
+[content_options.docs]
+extensions = [".md", ".markdoc"]
+recursive = false
+`), 0644)
+	cfg, err := sitemap.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	docs := cfg.ContentOptions("docs")
+	if !docs.Flat || len(docs.Extensions) != 2 || docs.Type != "docs" {
+		t.Errorf("Unexpected docs options: %+v", docs)
+	}
+	if blog := cfg.ContentOptions("blog"); blog.Flat || blog.Extensions != nil {
+		t.Errorf("Expected default blog options, got %+v", blog)
+	}
+}
+
+func TestConfigPublicURLs(t *testing.T) {
+	root := t.TempDir()
+	cfg := &sitemap.Config{OutputPath: "static/maps/sitemap.xml"}
+	cfg.ResolvePaths(root)
+	if got := cfg.SitemapDirURL("https://example.com/", cfg.OutputPath); got != "https://example.com/maps/" {
+		t.Errorf("Unexpected sitemap dir URL: %s", got)
+	}
+	if got := cfg.PublicURL("https://example.com", filepath.Join(root, "build", "news.xml")); got != "https://example.com/news.xml" {
+		t.Errorf("Unexpected build dir URL: %s", got)
+	}
+	if got := cfg.PublicURL("https://example.com", filepath.Join(root, "out", "sitemap.xml")); got != "https://example.com/sitemap.xml" {
+		t.Errorf("Files outside static and build should be served from the root, got %s", got)
+	}
+	if got := cfg.RobotsPath(cfg.OutputPath); got != filepath.Join(root, "static", "robots.txt") {
+		t.Errorf("Expected robots.txt at the static root, got %s", got)
+	}
+
+	cfg.SitemapURL = "https://cdn.example.com/seo/sitemap.xml"
+	if got := cfg.SitemapDirURL("https://example.com", cfg.OutputPath); got != "https://cdn.example.com/seo/" {
+		t.Errorf("Expected sitemap_url to win, got %s", got)
+	}
+}
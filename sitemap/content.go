@@ -1,68 +1,235 @@
 package sitemap
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
 
-type ContentMeta struct {
-	URL        string
-	LastMod    string
+// ContentOptions controls how ScanContentWithOptions reads content files.
+type ContentOptions struct {
+	// Type is the content type recorded on each entry.
+	Type       string
 	ChangeFreq string
+	// Priority is the default priority of the content type; frontmatter
+	// priority takes precedence.
+	Priority string
+	// LastModKeys lists the frontmatter keys holding the lastmod date, in
+	// order of precedence. Defaults to DefaultLastModKeys.
+	LastModKeys []string
+	// Git, when set, gives the lastmod of each article from its last commit,
+	// falling back to the frontmatter date and then the modification time.
+	Git *GitHistory
+	// Extensions are the file extensions read as content. Defaults to
+	// DefaultContentExtensions.
+	Extensions []string
+	// Flat only reads the files at the top of the directory instead of
+	// walking its subdirectories.
+	Flat bool
+	// Permalink is the URL template of the articles, such as
+	// "/blog/{year}/{month}/{slug}". By default the URL is the slug prefix
+	// followed by the file path.
+	Permalink string
 }
 
-// parsePublishDateFromFrontMatter extracts publishDate from YAML frontmatter if present
-func parsePublishDateFromFrontMatter(path string) string {
-	f, err := os.Open(path)
-	if err != nil {
-		return time.Now().Format("2006-01-02")
+// DefaultContentExtensions are the content file extensions read by default.
+var DefaultContentExtensions = []string{".md", ".svx"}
+
+// indexNames are the file names that stand for their directory URL.
+var indexNames = []string{"index", "+page"}
+
+// ScanContent returns an Entry (URL + lastmod + changefreq) for every article
+func ScanContent(root string, slugPrefix string, changefreq string) ([]Entry, error) {
+	return ScanContentWithOptions(root, slugPrefix, ContentOptions{ChangeFreq: changefreq})
+}
+
+// ScanContentWithOptions is like ScanContent but walks subdirectories, so
+// guides/setup/install.md is /<slugPrefix>/guides/setup/install, and index or
+// +page files stand for their directory. Each article can also override its own
+// entry through frontmatter: sitemap: false or draft: true leave it out, and
+// changefreq, priority and canonical replace the defaults.
+func ScanContentWithOptions(root string, slugPrefix string, opts ContentOptions) ([]Entry, error) {
+	return scanContent(context.Background(), root, slugPrefix, opts)
+}
+
+// scanContent is ScanContentWithOptions, stopping when ctx is done.
+func scanContent(ctx context.Context, root string, slugPrefix string, opts ContentOptions) ([]Entry, error) {
+	var metas []Entry
+	if fi, err := os.Stat(root); err != nil || !fi.IsDir() {
+		return []Entry{}, nil // If dir does not exist, just return empty
+	}
+	lastModKeys := opts.LastModKeys
+	if len(lastModKeys) == 0 {
+		lastModKeys = DefaultLastModKeys
+	}
+	extensions := opts.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultContentExtensions
 	}
-	defer f.Close()
+	type article struct {
+		path, slug, url string
+	}
+	var articles []article
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (opts.Flat || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		slug, ok := contentSlug(filepath.ToSlash(rel), extensions)
+		if !ok {
+			return nil
+		}
+		url := "/" + slugPrefix + "/" + slug
+		url = strings.ReplaceAll(url, "//", "/")
+		if len(url) > 1 {
+			url = strings.TrimSuffix(url, "/")
+		}
+		articles = append(articles, article{path: path, slug: slug, url: url})
+		return nil
+	})
 
-	scanner := bufio.NewScanner(f)
-	inFrontMatter := false
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "---" {
-			if !inFrontMatter {
-				inFrontMatter = true
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, a := range articles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// A file whose frontmatter cannot be read is skipped: its draft or
+		// sitemap keys are unknown, so it must not be published.
+		fm, body, err := parseContentFile(a.path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		url := a.url
+		if opts.Permalink != "" {
+			if url, err = permalinkURL(opts.Permalink, slugPrefix, a.slug, fm, lastModKeys); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", a.path, err))
 				continue
-			} else {
-				break
 			}
 		}
-		if inFrontMatter && strings.HasPrefix(line, "publishDate:") {
-			date := strings.TrimSpace(strings.TrimPrefix(line, "publishDate:"))
-			if len(date) >= 10 {
-				return date[:10]
+		meta, ok := contentMetaFromFrontMatter(fm, url, lastModKeys, opts.ChangeFreq, opts.Priority)
+		if !ok {
+			continue
+		}
+		if opts.Git != nil {
+			if lastmod, committed := opts.Git.LastMod(a.path); committed {
+				meta.LastMod = lastmod
+			} else if _, dated := fm.LastMod(lastModKeys); !dated {
+				meta.LastMod = fileModTime(a.path)
 			}
 		}
+		meta.Type = opts.Type
+		meta.Sources = []string{a.path}
+		meta.Images = imageRefsOf(append(frontMatterImages(fm), bodyImages(body)...))
+		meta.Videos = frontMatterVideos(fm)
+		metas = append(metas, meta)
 	}
-	return time.Now().Format("2006-01-02")
+	return metas, errors.Join(errs...)
 }
 
-// ScanContent returns a slice of ContentMeta (URL + lastmod + changefreq)
-func ScanContent(root string, slugPrefix string, changefreq string) ([]ContentMeta, error) {
-	var metas []ContentMeta
-	entries, err := os.ReadDir(root)
-	if err != nil {
-		return []ContentMeta{}, nil // If dir does not exist, just return empty
-	}
-	for _, entry := range entries {
-		if entry.IsDir() {
+// contentSlug returns the URL path of a content file relative to its content
+// directory, without extension: "guides/install.md" is "guides/install" and
+// "guides/index.md" is "guides". ok is false for files without one of the
+// given extensions.
+func contentSlug(rel string, extensions []string) (string, bool) {
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if !strings.HasSuffix(rel, ext) || len(rel) == len(ext) {
 			continue
 		}
-		name := entry.Name()
-		if strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".svx") {
-			slug := strings.TrimSuffix(strings.TrimSuffix(name, ".md"), ".svx")
-			url := "/" + slugPrefix + "/" + slug
-			url = strings.ReplaceAll(url, "//", "/")
-			lastmod := parsePublishDateFromFrontMatter(filepath.Join(root, name))
-			metas = append(metas, ContentMeta{URL: url, LastMod: lastmod, ChangeFreq: changefreq})
+		slug := strings.TrimSuffix(rel, ext)
+		dir, name := "", slug
+		if i := strings.LastIndex(slug, "/"); i >= 0 {
+			dir, name = slug[:i], slug[i+1:]
+		}
+		if slices.Contains(indexNames, name) {
+			slug = dir
+		}
+		return slug, true
+	}
+	return "", false
+}
+
+// contentMetaFromFrontMatter applies the per-article keys of fm to the entry
+// at url. It returns false when the article must be left out of the sitemap.
+func contentMetaFromFrontMatter(fm FrontMatter, url string, lastModKeys []string, changefreq, priority string) (Entry, bool) {
+	if draft, _ := fm.Bool("draft"); draft {
+		return Entry{}, false
+	}
+	// sitemap: false, or a nested sitemap: {changefreq: ..., priority: ...}
+	overrides := fm
+	if include, ok := fm.Bool("sitemap"); ok && !include {
+		return Entry{}, false
+	} else if nested := fm.Map("sitemap"); nested != nil {
+		if exclude, _ := nested.Bool("exclude"); exclude {
+			return Entry{}, false
+		}
+		overrides = nested
+	}
+
+	lastmod, ok := fm.LastMod(lastModKeys)
+	if !ok {
+		lastmod = dateOf(time.Now())
+	}
+
+	meta := Entry{
+		Loc:        url,
+		LastMod:    lastmod,
+		ChangeFreq: changefreq,
+		Priority:   priority,
+		Origin:     OriginContent,
+		Title:      fm.String("title"),
+		Keywords:   fm.Strings("keywords"),
+	}
+	if len(meta.Keywords) == 0 {
+		meta.Keywords = fm.Strings("tags")
+	}
+	for _, key := range PublishDateKeys {
+		if t, ok := fm.Time(key); ok {
+			meta.Published = t
+			break
+		}
+	}
+	if cf := firstString("changefreq", overrides, fm); cf != "" {
+		meta.ChangeFreq = cf
+	}
+	for _, m := range []FrontMatter{overrides, fm} {
+		if p, ok := m.Float("priority"); ok && p >= 0 && p <= 1 {
+			meta.Priority = formatPriority(p)
+			break
+		}
+	}
+	if canonical := firstString("canonical", overrides, fm); canonical != "" {
+		meta.Loc = canonical
+	}
+	return meta, true
+}
+
+// firstString returns the first non-empty value of key in the given maps.
+func firstString(key string, maps ...FrontMatter) string {
+	for _, m := range maps {
+		if v := m.String(key); v != "" {
+			return v
 		}
 	}
-	return metas, nil
+	return ""
 }
@@ -23,8 +23,8 @@ func TestScanContent(t *testing.T) {
 		t.Fatalf("got %d urls, want %d", len(urls), len(want))
 	}
 	for i, u := range want {
-		if urls[i].URL != u {
-			t.Errorf("got %q, want %q", urls[i].URL, u)
+		if urls[i].Loc != u {
+			t.Errorf("got %q, want %q", urls[i].Loc, u)
 		}
 	}
 }
@@ -50,7 +50,7 @@ func TestScanContent(t *testing.T) {
 // 	if err != nil {
 // 		t.Fatalf("unexpected error: %v", err)
 // 	}
-// 	if len(urls) != 1 || urls[0].URL != "/blog/foo" {
+// 	if len(urls) != 1 || urls[0].Loc != "/blog/foo" {
 // 		t.Errorf("expected /blog/foo, got %+v", urls)
 // 	}
 // }
@@ -70,7 +70,7 @@ func TestScanContentWithSvx(t *testing.T) {
 	}
 	found := make(map[string]bool)
 	for _, u := range urls {
-		found[u.URL] = true
+		found[u.Loc] = true
 	}
 	for _, u := range want {
 		if !found[u] {
@@ -106,12 +106,12 @@ blog = "` + blogDir + `"
 		t.Fatalf("failed to load config: %v", err)
 	}
 	routes, _ := sitemap.ScanRoutes(routesDir, cfg.Exclude)
-	allContent := []sitemap.ContentMeta{}
+	allContent := []sitemap.Entry{}
 	for slug, dir := range cfg.ContentTypes {
 		metas, _ := sitemap.ScanContent(dir, slug, "never")
 		allContent = append(allContent, metas...)
 	}
-	xml := sitemap.GenerateSitemap(cfg.BaseURL, routes, allContent, []sitemap.URL{}, false)
+	xml := sitemap.GenerateSitemap(cfg.BaseURL, append(routes, allContent...), []sitemap.Entry{}, false)
 	if !strings.Contains(xml, "/about") || !strings.Contains(xml, "/blog/foo") || !strings.Contains(xml, "/blog/bar") {
 		t.Errorf("sitemap missing expected urls: %s", xml)
 	}
@@ -119,3 +119,39 @@ blog = "` + blogDir + `"
 		t.Errorf("sitemap should not contain excluded url /admin: %s", xml)
 	}
 }
+
+func TestScanContentWithOptions_Nested(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guides", "setup"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".drafts"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "intro.mdx"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "guides", "+page.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "guides", "setup", "install.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "guides", "setup", "notes.txt"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, ".drafts", "wip.md"), []byte(""), 0644)
+
+	urls := func(opts sitemap.ContentOptions) []string {
+		metas, err := sitemap.ScanContentWithOptions(dir, "docs", opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, m := range metas {
+			got = append(got, m.Loc)
+		}
+		return got
+	}
+
+	got := urls(sitemap.ContentOptions{})
+	want := []string{"/docs/guides", "/docs/guides/setup/install", "/docs"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = urls(sitemap.ContentOptions{Extensions: []string{".md", "mdx"}, Flat: true})
+	want = []string{"/docs", "/docs/intro"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
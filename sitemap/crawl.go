@@ -0,0 +1,375 @@
+package sitemap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Crawl defaults.
+const (
+	DefaultCrawlMaxDepth    = 10
+	DefaultCrawlMaxPages    = 10000
+	DefaultCrawlConcurrency = 4
+	// CrawlUserAgent is sent with every request and matched against the
+	// User-agent groups of robots.txt.
+	CrawlUserAgent = "gositemap"
+)
+
+var (
+	anchorRe    = regexp.MustCompile(`(?is)<a\b([^>]*)>`)
+	metaRe      = regexp.MustCompile(`(?is)<meta\b([^>]*)>`)
+	baseHrefRe  = regexp.MustCompile(`(?is)<base\b([^>]*)>`)
+	attributeRe = regexp.MustCompile(`(?is)\b([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Crawl configures the crawler used with source = "crawl".
+type Crawl struct {
+	// StartURL is where crawling starts, e.g. a local "vite preview" server.
+	// Defaults to base_url. Pages found are listed under base_url.
+	StartURL string `toml:"start_url"`
+	// MaxDepth is the number of links followed from the start page.
+	MaxDepth int `toml:"max_depth"`
+	// MaxPages stops the crawl after this many pages are fetched.
+	MaxPages int `toml:"max_pages"`
+	// Concurrency is the number of pages fetched at the same time.
+	Concurrency int `toml:"concurrency"`
+}
+
+// CrawlSite fetches the start page and follows the links to pages on the same
+// host, breadth first, and returns an Entry for every page found. It
+// respects robots.txt, rel="nofollow" links and the noindex and nofollow robots
+// directives. URLs matching exclude are neither listed nor followed. It fails
+// when the start page cannot be fetched; other pages that fail are reported
+// in a ScanError returned with the pages found.
+func CrawlSite(c Crawl, exclude []string, client *http.Client) ([]Entry, error) {
+	return crawlSite(context.Background(), c, exclude, client)
+}
+
+// crawlSite is CrawlSite, aborting its requests when ctx is done.
+func crawlSite(ctx context.Context, c Crawl, exclude []string, client *http.Client) ([]Entry, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	start, err := url.Parse(c.StartURL)
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return nil, fmt.Errorf("invalid crawl start URL %q", c.StartURL)
+	}
+	if start.Path == "" {
+		start.Path = "/"
+	}
+	maxDepth, maxPages, concurrency := c.MaxDepth, c.MaxPages, c.Concurrency
+	if maxDepth <= 0 {
+		maxDepth = DefaultCrawlMaxDepth
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultCrawlMaxPages
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultCrawlConcurrency
+	}
+
+	robots := fetchRobots(ctx, client, start)
+	if !robots.allowed(start.EscapedPath()) {
+		return nil, fmt.Errorf("start URL %s is disallowed by robots.txt", start)
+	}
+	seen := map[string]bool{start.Path: true}
+	frontier := []string{start.Path}
+	fetched := 0
+	var metas []Entry
+	var failed []error
+
+	for depth := 0; depth <= maxDepth && len(frontier) > 0 && fetched < maxPages; depth++ {
+		if len(frontier) > maxPages-fetched {
+			frontier = frontier[:maxPages-fetched]
+		}
+		fetched += len(frontier)
+
+		pages := make([]crawledPage, len(frontier))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i, p := range frontier {
+			wg.Add(1)
+			go func(i int, p string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if u, err := start.Parse(p); err == nil {
+					pages[i] = fetchPage(ctx, client, u)
+				}
+			}(i, p)
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for _, page := range pages {
+			if page.err != nil {
+				if depth == 0 {
+					return nil, fmt.Errorf("fetching start page: %w", page.err)
+				}
+				failed = append(failed, page.err)
+			}
+			if page.meta != nil {
+				metas = append(metas, *page.meta)
+			}
+			if depth == maxDepth {
+				continue
+			}
+			for _, link := range page.links {
+				if link.Host != start.Host || link.Scheme != start.Scheme || link.RawQuery != "" {
+					continue
+				}
+				p := link.EscapedPath()
+				if p == "" {
+					p = "/"
+				}
+				if seen[p] || isExcluded(p, exclude) || !robots.allowed(p) {
+					continue
+				}
+				seen[p] = true
+				next = append(next, p)
+			}
+		}
+		frontier = next
+	}
+
+	// Redirects can lead several links to the same page.
+	var listed []Entry
+	found := make(map[string]bool, len(metas))
+	for _, m := range metas {
+		if !found[m.Loc] && !isExcluded(m.Loc, exclude) {
+			found[m.Loc] = true
+			listed = append(listed, m)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Loc < listed[j].Loc })
+	if len(failed) > 0 {
+		return listed, &ScanError{Path: start.String(), Err: fmt.Errorf("%d page(s) could not be fetched: %w", len(failed), errors.Join(failed...))}
+	}
+	return listed, nil
+}
+
+// crawledPage is the result of fetching one page: its entry, unless it must
+// not be indexed, and the links to follow. err is set when the page could not
+// be fetched.
+type crawledPage struct {
+	meta  *Entry
+	links []*url.URL
+	err   error
+}
+
+func fetchPage(ctx context.Context, client *http.Client, u *url.URL) crawledPage {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return crawledPage{err: err}
+	}
+	req.Header.Set("User-Agent", CrawlUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return crawledPage{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return crawledPage{err: fmt.Errorf("%s: %s", u, resp.Status)}
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return crawledPage{}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxSitemapBytes))
+	if err != nil {
+		return crawledPage{err: fmt.Errorf("%s: %w", u, err)}
+	}
+
+	// After a redirect, the page is listed under its final URL.
+	final := resp.Request.URL
+	noindex, nofollow := robotsDirectives(resp.Header.Values("X-Robots-Tag"))
+	for _, attrs := range metaRe.FindAllSubmatch(body, -1) {
+		a := parseAttributes(string(attrs[1]))
+		if name := strings.ToLower(a["name"]); name == "robots" || name == CrawlUserAgent {
+			ni, nf := robotsDirectives([]string{a["content"]})
+			noindex, nofollow = noindex || ni, nofollow || nf
+		}
+	}
+
+	var page crawledPage
+	if !noindex && final.Host == u.Host {
+		p := final.EscapedPath()
+		if p == "" {
+			p = "/"
+		}
+		lastmod := dateOf(time.Now())
+		if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			lastmod = dateOf(t)
+		}
+		page.meta = &Entry{
+			Loc:        p,
+			LastMod:    lastmod,
+			ChangeFreq: defaultRouteChangeFreq(strings.TrimSuffix(p, "/")),
+			Images:     imageRefsOf(bodyImages(body)),
+			Origin:     OriginCrawl,
+		}
+	}
+	if nofollow {
+		return page
+	}
+
+	base := final
+	if m := baseHrefRe.FindSubmatch(body); m != nil {
+		if href := parseAttributes(string(m[1]))["href"]; href != "" {
+			if b, err := final.Parse(href); err == nil {
+				base = b
+			}
+		}
+	}
+	for _, attrs := range anchorRe.FindAllSubmatch(body, -1) {
+		a := parseAttributes(string(attrs[1]))
+		href := strings.TrimSpace(a["href"])
+		if href == "" || strings.HasPrefix(href, "#") || hasToken(a["rel"], "nofollow") {
+			continue
+		}
+		link, err := base.Parse(href)
+		if err != nil {
+			continue
+		}
+		link.Fragment = ""
+		page.links = append(page.links, link)
+	}
+	return page
+}
+
+// parseAttributes returns the attributes of an HTML tag, with lowercase names
+// and unescaped values.
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attributeRe.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+// robotsDirectives reads the noindex and nofollow directives of robots meta
+// tags or X-Robots-Tag headers. "none" means both.
+func robotsDirectives(values []string) (noindex, nofollow bool) {
+	for _, v := range values {
+		noindex = noindex || hasToken(v, "noindex") || hasToken(v, "none")
+		nofollow = nofollow || hasToken(v, "nofollow") || hasToken(v, "none")
+	}
+	return noindex, nofollow
+}
+
+// hasToken reports whether a comma or space separated list holds token.
+func hasToken(list, token string) bool {
+	for _, f := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsRules are the Allow and Disallow rules of robots.txt that apply to the
+// crawler.
+type robotsRules []robotsRule
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// fetchRobots reads the robots.txt of the start URL's host. A missing or
+// unreadable file allows everything.
+func fetchRobots(ctx context.Context, client *http.Client, start *url.URL) robotsRules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, start.ResolveReference(&url.URL{Path: "/robots.txt"}).String(), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", CrawlUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(resp.Body, CrawlUserAgent)
+}
+
+// parseRobots returns the rules of the group for agent, or of the * group when
+// there is none.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	groups := make(map[string]robotsRules)
+	var agents []string
+	inRules := false
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)}
+			for _, a := range agents {
+				groups[a] = append(groups[a], rule)
+			}
+		}
+	}
+	if rules, ok := groups[strings.ToLower(agent)]; ok {
+		return rules
+	}
+	return groups["*"]
+}
+
+// robotsPattern compiles a robots.txt path pattern, where * matches anything
+// and a trailing $ anchors the end.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether path may be crawled: the longest matching rule
+// wins, and Allow wins a tie.
+func (rules robotsRules) allowed(path string) bool {
+	allow, length := true, -1
+	for _, r := range rules {
+		if !r.re.MatchString(path) {
+			continue
+		}
+		if len(r.pattern) > length || (len(r.pattern) == length && r.allow) {
+			allow, length = r.allow, len(r.pattern)
+		}
+	}
+	return allow
+}
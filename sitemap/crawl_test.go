@@ -0,0 +1,142 @@
+package sitemap_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/": `<a href="/about">About</a> <a href='blog/'>Blog</a> <a href="/private/x">P</a>
+			<a href="/sponsor" rel="sponsored nofollow">S</a> <a href="https://other.example/">O</a>
+			<a href="/search?q=x">Q</a> <a href="#top">Top</a> <a href="/admin">A</a>`,
+		"/about":       `<img src="/team.png"><a href="/about#team">Team</a>`,
+		"/blog/":       `<a href="post-1">1</a><a href="/old">Old</a><a href="/hidden">H</a>`,
+		"/blog/post-1": `<a href="/blog/post-2">2</a>`,
+		"/blog/post-2": `<a href="/blog/post-3">3</a>`,
+		"/blog/post-3": `end`,
+		"/hidden":      `<meta name="robots" content="noindex"><a href="/from-hidden">F</a>`,
+		"/from-hidden": `<meta name="robots" content="nofollow"><a href="/never">N</a>`,
+		"/never":       `never`,
+		"/private/x":   `private`,
+		"/sponsor":     `sponsor`,
+		"/admin":       `admin`,
+		"/search":      `search`,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/about", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/about" {
+			w.Header().Set("Last-Modified", "Wed, 01 May 2024 10:00:00 GMT")
+		}
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlSite(t *testing.T) {
+	srv := newTestSite(t)
+	metas, err := sitemap.CrawlSite(sitemap.Crawl{StartURL: srv.URL}, []string{"/admin"}, srv.Client())
+	if err != nil {
+		t.Fatalf("CrawlSite failed: %v", err)
+	}
+	var urls []string
+	for _, m := range metas {
+		urls = append(urls, m.Loc)
+	}
+	want := "/,/about,/blog/,/blog/post-1,/blog/post-2,/blog/post-3,/from-hidden"
+	if got := strings.Join(urls, ","); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if metas[1].LastModString() != "2024-05-01" || len(metas[1].Images) != 1 || metas[1].Images[0].Loc != "/team.png" {
+		t.Errorf("Unexpected /about entry: %+v", metas[1])
+	}
+
+	metas, err = sitemap.CrawlSite(sitemap.Crawl{StartURL: srv.URL, MaxDepth: 2, Concurrency: 1}, nil, srv.Client())
+	if err != nil {
+		t.Fatalf("CrawlSite failed: %v", err)
+	}
+	for _, m := range metas {
+		if m.Loc == "/blog/post-2" {
+			t.Errorf("Expected max_depth 2 to stop before /blog/post-2")
+		}
+	}
+
+	metas, _ = sitemap.CrawlSite(sitemap.Crawl{StartURL: srv.URL, MaxPages: 3}, nil, srv.Client())
+	if len(metas) > 3 {
+		t.Errorf("Expected at most 3 pages, got %d", len(metas))
+	}
+}
+
+func TestCrawlSite_FeedsMerge(t *testing.T) {
+	srv := newTestSite(t)
+	metas, err := sitemap.CrawlSite(sitemap.Crawl{StartURL: srv.URL, MaxDepth: 1}, nil, srv.Client())
+	if err != nil {
+		t.Fatalf("CrawlSite failed: %v", err)
+	}
+	xml := sitemap.GenerateSitemap("https://example.com", metas, nil, false)
+	if !strings.Contains(xml, "<loc>https://example.com/about</loc>") || strings.Contains(xml, srv.URL) {
+		t.Errorf("Expected crawled pages under base_url: %s", xml)
+	}
+	if !strings.Contains(xml, "<image:loc>https://example.com/team.png</image:loc>") {
+		t.Errorf("Expected images resolved against base_url: %s", xml)
+	}
+}
+
+func TestCrawlSite_Failures(t *testing.T) {
+	srv := newTestSite(t)
+
+	// A broken link is a warning, the pages found are kept.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="/broken">B</a>`)
+	})
+	broken := httptest.NewServer(mux)
+	defer broken.Close()
+	metas, err := sitemap.CrawlSite(sitemap.Crawl{StartURL: broken.URL}, nil, broken.Client())
+	var scanErr *sitemap.ScanError
+	if !errors.As(err, &scanErr) || !strings.Contains(err.Error(), "/broken") {
+		t.Errorf("Expected a ScanError for /broken, got %v", err)
+	}
+	if len(metas) != 1 || metas[0].Loc != "/" {
+		t.Errorf("Expected the start page to be kept, got %+v", metas)
+	}
+
+	// A start page that cannot be fetched or is disallowed is an error.
+	for _, start := range []string{srv.URL + "/missing", srv.URL + "/private/x"} {
+		_, err := sitemap.CrawlSite(sitemap.Crawl{StartURL: start}, nil, srv.Client())
+		if err == nil || errors.As(err, &scanErr) {
+			t.Errorf("%s: expected an error, got %v", start, err)
+		}
+	}
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if _, err := sitemap.CrawlSite(sitemap.Crawl{StartURL: down.URL}, nil, nil); err == nil {
+		t.Error("Expected an error when the server is down")
+	}
+}
@@ -0,0 +1,98 @@
+package sitemap
+
+import (
+	"strings"
+	"time"
+)
+
+// Origins recorded on each Entry.
+const (
+	OriginRoutes  = "routes"
+	OriginBuild   = "build"
+	OriginCrawl   = "crawl"
+	OriginContent = "content"
+	// OriginSitemap marks entries read from an existing sitemap.
+	OriginSitemap = "sitemap"
+)
+
+// lastModLayouts are the W3C datetime formats read from <lastmod>.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Entry is a page of the sitemap, from the scanner that found it to the
+// <url> it is written as. Scanners return Loc as a path, e.g. /blog/hello,
+// and images and videos as written in the page; MergeEntries resolves them
+// against the base URL.
+type Entry struct {
+	// Loc is the page location. Articles may also hold an absolute URL
+	// taken from their canonical frontmatter.
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   string
+	Images     []Image
+	Videos     []Video
+	Alternates []Alternate
+	// Sources are the files the page is built from, used to detect changes.
+	Sources []string
+	// Origin is the scanner that found the entry, one of the Origin
+	// constants or the name of a custom source.
+	Origin string
+	// Type, Title, Keywords and Published are set on articles and used by the
+	// news sitemap.
+	Type      string
+	Title     string
+	Keywords  []string
+	Published time.Time
+}
+
+// IsArticle reports whether the entry comes from a content directory. The
+// changefreq of articles defaults to "never".
+func (e Entry) IsArticle() bool {
+	return e.Origin == OriginContent
+}
+
+// LastModString formats LastMod for <lastmod>, or returns "" when it is not
+// set.
+func (e Entry) LastModString() string {
+	if e.LastMod.IsZero() {
+		return ""
+	}
+	return formatLastMod(e.LastMod)
+}
+
+// parseLastMod reads a <lastmod> value. ok is false when s is empty or not a
+// W3C datetime.
+func parseLastMod(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateOf returns the calendar day of t, which formatLastMod writes as a plain
+// date.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// imageRefsOf wraps image references in Image values, resolved later by
+// MergeEntries.
+func imageRefsOf(refs []string) []Image {
+	if len(refs) == 0 {
+		return nil
+	}
+	images := make([]Image, 0, len(refs))
+	for _, ref := range refs {
+		images = append(images, Image{Loc: ref})
+	}
+	return images
+}
@@ -0,0 +1,33 @@
+package sitemap_test
+
+import (
+	"testing"
+	"time"
+
+	"gositemap/sitemap"
+)
+
+// day returns the date s, given as 2006-01-02, as a lastmod.
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestEntryLastModString(t *testing.T) {
+	tests := []struct {
+		lastmod time.Time
+		want    string
+	}{
+		{time.Time{}, ""},
+		{day("2024-01-02"), "2024-01-02"},
+		{time.Date(2024, 1, 2, 10, 30, 0, 0, time.FixedZone("", 2*3600)), "2024-01-02T10:30:00+02:00"},
+	}
+	for _, tt := range tests {
+		if got := (sitemap.Entry{LastMod: tt.lastmod}).LastModString(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLastModKeys are the frontmatter keys read for lastmod, in order of
+// precedence, when lastmod_keys is not set in the config.
+var DefaultLastModKeys = []string{"lastmod", "updated", "updatedAt", "publishDate", "date"}
+
+// PublishDateKeys are the frontmatter keys read for the publication date of an
+// article, in order of precedence.
+var PublishDateKeys = []string{"publishDate", "published", "publishedAt", "date"}
+
+// dateLayouts are the date formats accepted in frontmatter besides the YAML
+// timestamps decoded by the parser.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FrontMatter holds the YAML frontmatter of a content file.
+type FrontMatter map[string]any
+
+// ParseFrontMatter reads the YAML block between the leading "---" lines of a
+// file. A file without frontmatter returns an empty FrontMatter.
+func ParseFrontMatter(path string) (FrontMatter, error) {
+	fm, _, err := parseContentFile(path)
+	return fm, err
+}
+
+// parseContentFile returns the frontmatter and the body of a content file.
+func parseContentFile(path string) (FrontMatter, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, body, ok := splitFrontMatter(data)
+	if !ok {
+		return FrontMatter{}, data, nil
+	}
+	fm := FrontMatter{}
+	if err := yaml.Unmarshal(block, &fm); err != nil {
+		return FrontMatter{}, body, fmt.Errorf("%s: invalid frontmatter: %w", path, err)
+	}
+	return fm, body, nil
+}
+
+// splitFrontMatter splits data into its frontmatter block and body. ok is
+// false when data does not start with a closed "---" block.
+func splitFrontMatter(data []byte) (block, body []byte, ok bool) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	line, rest, _ := bytes.Cut(data, []byte("\n"))
+	if string(bytes.TrimSpace(line)) != "---" {
+		return nil, data, false
+	}
+	offset := 0
+	for len(rest[offset:]) > 0 {
+		line, _, found := bytes.Cut(rest[offset:], []byte("\n"))
+		if trimmed := string(bytes.TrimSpace(line)); trimmed == "---" || trimmed == "..." {
+			end := offset + len(line)
+			if found {
+				end++
+			}
+			return rest[:offset], rest[end:], true
+		}
+		if !found {
+			break
+		}
+		offset += len(line) + 1
+	}
+	return nil, data, false
+}
+
+// String returns the value of key as a string, or "" if it is missing.
+func (fm FrontMatter) String(key string) string {
+	switch v := fm[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		return formatLastMod(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Strings returns the value of key as a list of strings. A single string is
+// split on commas.
+func (fm FrontMatter) Strings(key string) []string {
+	var values []string
+	switch v := fm[key].(type) {
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" && item != nil {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// Bool returns the value of key as a bool and whether it was set.
+func (fm FrontMatter) Bool(key string) (bool, bool) {
+	switch v := fm[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// Float returns the value of key as a float64 and whether it was set.
+func (fm FrontMatter) Float(key string) (float64, bool) {
+	switch v := fm[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Map returns the value of key as a nested FrontMatter, or nil.
+func (fm FrontMatter) Map(key string) FrontMatter {
+	switch m := fm[key].(type) {
+	case FrontMatter:
+		return m
+	case map[string]any:
+		return FrontMatter(m)
+	}
+	return nil
+}
+
+// Time returns the value of key as a time and whether it could be parsed.
+func (fm FrontMatter) Time(key string) (time.Time, bool) {
+	switch v := fm[key].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return parseDate(v)
+	}
+	return time.Time{}, false
+}
+
+// LastMod returns the first date found under keys.
+func (fm FrontMatter) LastMod(keys []string) (time.Time, bool) {
+	for _, key := range keys {
+		if t, ok := fm.Time(key); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatLastMod formats t as a W3C datetime: a plain date when t has no time
+// of day, a full timestamp with its timezone otherwise.
+func formatLastMod(t time.Time) string {
+	if t.Location() == time.UTC && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatPriority formats a priority between 0.0 and 1.0 for <priority>.
+func formatPriority(p float64) string {
+	s := strconv.FormatFloat(p, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
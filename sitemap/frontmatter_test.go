@@ -0,0 +1,103 @@
+package sitemap_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "post.md")
+	os.WriteFile(file, []byte("---\ntitle: \"Hello: world\"\npublishDate: '2023-05-01'\nupdated: 2024-02-03T10:30:00+02:00\ndraft: false\ntags:\n  - go\n---\n# Body\n---\n"), 0644)
+
+	fm, err := sitemap.ParseFrontMatter(file)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter failed: %v", err)
+	}
+	if got := fm.String("title"); got != "Hello: world" {
+		t.Errorf("Expected quoted title, got %q", got)
+	}
+	if got, _ := fm.LastMod([]string{"publishDate"}); !got.Equal(day("2023-05-01")) {
+		t.Errorf("Expected quoted publishDate, got %s", got)
+	}
+	if got, _ := fm.LastMod(sitemap.DefaultLastModKeys); got.Format("2006-01-02T15:04:05Z07:00") != "2024-02-03T10:30:00+02:00" {
+		t.Errorf("Expected updated to take precedence with its timezone, got %s", got)
+	}
+
+	empty := filepath.Join(dir, "empty.md")
+	os.WriteFile(empty, []byte("# No frontmatter\n"), 0644)
+	fm, err = sitemap.ParseFrontMatter(empty)
+	if err != nil || len(fm) != 0 {
+		t.Errorf("Expected empty frontmatter, got %v (%v)", fm, err)
+	}
+}
+
+func TestScanContentWithOptions_FrontMatterOverrides(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "draft.md"), []byte("---\ndraft: true\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "hidden.md"), []byte("---\nsitemap: false\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "custom.md"), []byte("---\ndate: 2023-01-01\nlastmod: \"2024-03-04 08:00:00\"\nchangefreq: daily\npriority: 0.9\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "nested.md"), []byte("---\ndate: 2023-01-01\nsitemap:\n  changefreq: yearly\n  priority: 0.2\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "moved.md"), []byte("---\ncanonical: /guides/moved\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "syndicated.md"), []byte("---\ncanonical: https://other.example.com/post\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "dated.md"), []byte("---\ndate: 2022-12-01\nupdated: 2023-06-01\n---\n"), 0644)
+
+	metas, err := sitemap.ScanContentWithOptions(dir, "blog", sitemap.ContentOptions{
+		ChangeFreq:  "weekly",
+		LastModKeys: []string{"lastmod", "date"},
+	})
+	if err != nil {
+		t.Fatalf("ScanContentWithOptions failed: %v", err)
+	}
+	byURL := map[string]sitemap.Entry{}
+	for _, m := range metas {
+		byURL[m.Loc] = m
+	}
+	if len(metas) != 5 {
+		t.Errorf("Expected drafts and sitemap: false to be skipped, got %+v", metas)
+	}
+
+	custom := byURL["/blog/custom"]
+	if custom.LastModString() != "2024-03-04T08:00:00Z" || custom.ChangeFreq != "daily" || custom.Priority != "0.9" {
+		t.Errorf("Frontmatter overrides not applied: %+v", custom)
+	}
+	nested := byURL["/blog/nested"]
+	if nested.ChangeFreq != "yearly" || nested.Priority != "0.2" {
+		t.Errorf("Nested sitemap overrides not applied: %+v", nested)
+	}
+	if _, ok := byURL["/guides/moved"]; !ok {
+		t.Errorf("Expected canonical path to replace the URL: %+v", metas)
+	}
+	if dated := byURL["/blog/dated"]; dated.LastModString() != "2022-12-01" || dated.ChangeFreq != "weekly" {
+		t.Errorf("Expected configured key precedence and default changefreq: %+v", dated)
+	}
+
+	xml := sitemap.GenerateSitemap("https://example.com", metas, nil, false)
+	if !strings.Contains(xml, "<loc>https://other.example.com/post</loc>") {
+		t.Errorf("Expected absolute canonical to be kept: %s", xml)
+	}
+	if !strings.Contains(xml, "<priority>0.9</priority>") {
+		t.Errorf("Expected priority in sitemap: %s", xml)
+	}
+}
+
+func TestContentSource_InvalidFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.md"), []byte("---\ndraft: true\ntitle: [oops\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "good.md"), []byte("---\ndate: 2024-01-01\n---\n"), 0644)
+
+	entries, err := sitemap.ContentSource{Dir: dir, Prefix: "blog"}.Scan(context.Background())
+	var scanErr *sitemap.ScanError
+	if !errors.As(err, &scanErr) || !strings.Contains(err.Error(), "broken.md") {
+		t.Errorf("Expected a ScanError naming broken.md, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].Loc != "/blog/good" {
+		t.Errorf("Expected the broken file to be skipped, got %+v", entries)
+	}
+}
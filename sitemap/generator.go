@@ -0,0 +1,420 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Generator builds a sitemap from the entries of its sources, merged with the
+// entries of an existing sitemap.
+type Generator struct {
+	Config *Config
+	// Base is the base URL without trailing slash.
+	Base    string
+	Sources []Source
+	// Existing are the entries of the sitemap being updated. They are kept
+	// unless preserve_existing is false or prune drops them.
+	Existing []Entry
+	// Git is the history used with lastmod_source = "git".
+	Git *GitHistory
+	// State is the content-hash state used with lastmod_source = "hash". It
+	// is updated by Generate; the caller saves it once the sitemap is written.
+	State *State
+	// Now returns the current time, used for hash-mode dates and the news
+	// sitemap.
+	Now func() time.Time
+	// DirURL is the public URL of the directory the sitemap files are served
+	// from, used by the links of a sitemap index. Defaults to Base + "/".
+	DirURL string
+	// Jobs is the number of sources scanned at once. It defaults to the
+	// workers of Config.
+	Jobs int
+
+	warnings []error
+	result   *Result
+	// scans are the outcomes of the last scan, reused by Regenerate.
+	scans []scanned
+}
+
+// Result is the outcome of Generate.
+type Result struct {
+	// Found are the pages found by the sources, in source order.
+	Found []Entry
+	// Entries are the sitemap entries, sorted by location.
+	Entries []Entry
+	// Removed are the existing entries dropped by prune.
+	Removed []Entry
+	// Warnings are problems that did not stop the generation.
+	Warnings []error
+}
+
+// NewGenerator checks cfg and returns a generator with the sources it
+// configures: the route source (routes, build or crawl) followed by the
+// content types, glob patterns and i18n content. The paths of cfg must have
+// been resolved with ResolvePaths.
+func NewGenerator(cfg *Config) (*Generator, error) {
+	base := "http://localhost"
+	if cfg.BaseURL != "" {
+		base = cfg.BaseURL
+	}
+	parsed, err := url.Parse(base)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, &ConfigError{Key: "base_url", Err: errors.New("must be a valid URL (e.g. https://mysite.com)")}
+	}
+	if cfg.I18n != nil {
+		if err := cfg.I18n.Validate(); err != nil {
+			return nil, &ConfigError{Key: "i18n", Err: err}
+		}
+	}
+	if err := ValidatePriorities(cfg.Priority); err != nil {
+		return nil, &ConfigError{Key: "priority", Err: err}
+	}
+	if cfg.News != nil {
+		if err := cfg.News.Validate(); err != nil {
+			return nil, &ConfigError{Key: "news", Err: err}
+		}
+	}
+
+	g := &Generator{Config: cfg, Base: strings.TrimRight(base, "/"), Now: time.Now, Jobs: cfg.Workers()}
+	switch cfg.LastModSource {
+	case "", LastModSourceMtime:
+	case LastModSourceGit:
+		g.Git, err = LoadGitHistory(cfg.ProjectRoot)
+		if err != nil {
+			g.Git = nil
+			g.warnings = append(g.warnings, fmt.Errorf("could not read git history, using file dates: %w", err))
+		} else if g.Git.Shallow {
+			g.warnings = append(g.warnings, errors.New("shallow git clone, lastmod of files older than the clone depth will be wrong (use fetch-depth: 0 in CI)"))
+		}
+	case LastModSourceHash:
+		g.State, err = LoadState(cfg.StateFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %s: %w", cfg.StateFile, err)
+		}
+	default:
+		return nil, &ConfigError{Key: "lastmod_source", Err: fmt.Errorf("%q (must be \"mtime\", \"git\" or \"hash\")", cfg.LastModSource)}
+	}
+
+	switch cfg.Source {
+	case "", OriginRoutes:
+		var params map[string][]string
+		if cfg.I18n != nil {
+			params = cfg.I18n.RouteParams()
+		}
+		g.Sources = append(g.Sources, RoutesSource{Dir: cfg.RoutesDir, Options: RouteOptions{
+			Exclude: cfg.Exclude,
+			Dynamic: cfg.Dynamic,
+			Params:  params,
+			Git:     g.Git,
+		}})
+	case OriginBuild:
+		g.Sources = append(g.Sources, BuildSource{Dir: cfg.BuildDir, Options: BuildOptions{
+			Exclude:        cfg.Exclude,
+			PrerenderedDir: cfg.Path(DefaultPrerendered),
+			TrailingSlash:  cfg.TrailingSlash,
+		}})
+	case OriginCrawl:
+		crawl := Crawl{}
+		if cfg.Crawl != nil {
+			crawl = *cfg.Crawl
+		}
+		if crawl.StartURL == "" {
+			crawl.StartURL = g.Base
+		}
+		g.Sources = append(g.Sources, CrawlSource{Crawl: crawl, Exclude: cfg.Exclude})
+	default:
+		return nil, &ConfigError{Key: "source", Err: fmt.Errorf("%q (must be \"routes\", \"build\" or \"crawl\")", cfg.Source)}
+	}
+
+	contentTypes := map[string]string{"blog": cfg.Path(DefaultContentDir)}
+	if len(cfg.ContentTypes) > 0 {
+		contentTypes = cfg.ContentTypes
+	}
+	// Map keys are sorted so that sources, and the pages they find, always
+	// come in the same order.
+	for _, slug := range sortedKeys(contentTypes) {
+		g.Sources = append(g.Sources, ContentSource{Dir: contentTypes[slug], Prefix: slug, Options: g.contentOptions(slug)})
+	}
+	for _, glob := range cfg.Glob {
+		g.Sources = append(g.Sources, GlobSource{Patterns: glob.Paths, Config: cfg, Git: g.Git})
+	}
+	if cfg.I18n != nil {
+		for _, slug := range sortedKeys(cfg.I18n.Content) {
+			dirs := cfg.I18n.Content[slug]
+			for _, locale := range sortedKeys(dirs) {
+				dir := dirs[locale]
+				opts := g.contentOptions(slug)
+				if opts.Permalink != "" && !strings.Contains(opts.Permalink, "{prefix}") {
+					// {prefix} holds the locale, otherwise add it in front.
+					opts.Permalink = cfg.I18n.URLPrefix(locale) + opts.Permalink
+				}
+				prefix := strings.TrimPrefix(cfg.I18n.URLPrefix(locale)+"/"+slug, "/")
+				g.Sources = append(g.Sources, ContentSource{Dir: dir, Prefix: prefix, Options: opts})
+			}
+		}
+	}
+	return g, nil
+}
+
+// contentOptions returns the scan options of a content type.
+func (g *Generator) contentOptions(slug string) ContentOptions {
+	opts := g.Config.ContentOptions(slug)
+	opts.ChangeFreq = "never"
+	if f := g.Config.ChangeFreq[slug]; f != "" {
+		opts.ChangeFreq = f
+	}
+	opts.Git = g.Git
+	return opts
+}
+
+// AddSource adds a source scanned after the configured ones.
+func (g *Generator) AddSource(s Source) {
+	g.Sources = append(g.Sources, s)
+}
+
+// Generate scans the sources concurrently, Jobs at a time, and builds the
+// sitemap entries. Errors of a source stop the generation, except ScanErrors
+// which are returned as warnings. The pages found are kept in source order.
+func (g *Generator) Generate(ctx context.Context) (*Result, error) {
+	return g.generate(ctx, nil)
+}
+
+// Regenerate is like Generate but only rescans the sources reading one of the
+// changed paths, reusing what the others found last time. Sources that are
+// not a FileSource are always rescanned.
+func (g *Generator) Regenerate(ctx context.Context, changed []string) (*Result, error) {
+	if len(g.scans) != len(g.Sources) {
+		return g.Generate(ctx)
+	}
+	return g.generate(ctx, func(s Source) bool {
+		fs, ok := s.(FileSource)
+		if !ok {
+			return true
+		}
+		for _, root := range fs.Paths() {
+			for _, p := range changed {
+				if within(root, p) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// WatchPaths returns the files and directories read by the sources.
+func (g *Generator) WatchPaths() []string {
+	var paths []string
+	for _, s := range g.Sources {
+		if fs, ok := s.(FileSource); ok {
+			paths = append(paths, fs.Paths()...)
+		}
+	}
+	return paths
+}
+
+// generate scans the sources for which rescan returns true, or all of them
+// when rescan is nil, and builds the sitemap entries.
+func (g *Generator) generate(ctx context.Context, rescan func(Source) bool) (*Result, error) {
+	res := &Result{Warnings: append([]error(nil), g.warnings...)}
+	found, err := g.scan(ctx, rescan)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range found {
+		var scanErr *ScanError
+		if errors.As(s.err, &scanErr) {
+			res.Warnings = append(res.Warnings, s.err)
+		}
+		for _, e := range s.entries {
+			if err := g.checkHost(e); err != nil {
+				res.Warnings = append(res.Warnings, err)
+				continue
+			}
+			res.Found = append(res.Found, e)
+		}
+	}
+
+	cfg := g.Config
+	overwrite := cfg.PreserveExisting != nil && !*cfg.PreserveExisting
+	urls := MergeEntries(g.Base, res.Found, g.Existing, overwrite)
+	if cfg.Prune {
+		urls, res.Removed = PruneEntries(urls, g.Base, res.Found, cfg.Keep)
+	}
+	if g.State != nil {
+		hashes, err := SourceHashes(g.Base, res.Found)
+		if err != nil {
+			return nil, fmt.Errorf("hashing sources: %w", err)
+		}
+		urls = g.State.Apply(urls, hashes, g.now())
+	}
+	urls = ApplyPriorities(urls, g.Base, cfg.Priority, cfg.AutoPriority)
+	if cfg.I18n != nil {
+		urls = ApplyAlternates(urls, g.Base, *cfg.I18n)
+		res.Warnings = append(res.Warnings, ValidateAlternates(urls)...)
+	}
+	if errs := ValidateVideos(urls); len(errs) > 0 {
+		return nil, fmt.Errorf("%d invalid video(s): %w", len(errs), errors.Join(errs...))
+	}
+	res.Entries = urls
+	g.result = res
+	return res, nil
+}
+
+// checkHost returns a ScanError when e has an absolute URL, such as a
+// canonical, on another host than Base. Search engines reject such URLs, so
+// the entry is left out.
+func (g *Generator) checkHost(e Entry) error {
+	if !strings.HasPrefix(e.Loc, "http://") && !strings.HasPrefix(e.Loc, "https://") {
+		return nil
+	}
+	loc, err := url.Parse(e.Loc)
+	base, baseErr := url.Parse(g.Base)
+	if err == nil && baseErr == nil && strings.EqualFold(loc.Host, base.Host) {
+		return nil
+	}
+	path := e.Loc
+	if len(e.Sources) > 0 {
+		path = e.Sources[0]
+	}
+	return &ScanError{Path: path, Err: fmt.Errorf("%s is not on %s, left out of the sitemap", e.Loc, g.Base)}
+}
+
+// scanned is the outcome of one source.
+type scanned struct {
+	entries []Entry
+	err     error
+}
+
+// scan runs the sources and returns their outcomes in source order. Sources
+// that rescan rejects keep their previous outcome. The first error that is not
+// a ScanError cancels the remaining sources and is returned.
+func (g *Generator) scan(ctx context.Context, rescan func(Source) bool) ([]scanned, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := g.Jobs
+	if jobs < 1 {
+		jobs = g.Config.Workers()
+	}
+	found := make([]scanned, len(g.Sources))
+	var todo []int
+	for i, s := range g.Sources {
+		if rescan == nil || rescan(s) {
+			todo = append(todo, i)
+		} else {
+			found[i] = g.scans[i]
+		}
+	}
+	forEach(scanCtx, jobs, len(todo), func(j int) {
+		i := todo[j]
+		entries, err := g.Sources[i].Scan(scanCtx)
+		var scanErr *ScanError
+		if err != nil && !errors.As(err, &scanErr) {
+			cancel()
+		}
+		found[i] = scanned{entries, err}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Sources cancelled because of another one's error report
+	// context.Canceled, so look for the error that caused it.
+	var canceled error
+	for _, s := range found {
+		var scanErr *ScanError
+		if s.err == nil || errors.As(s.err, &scanErr) {
+			continue
+		}
+		if !errors.Is(s.err, context.Canceled) {
+			return nil, s.err
+		}
+		if canceled == nil {
+			canceled = s.err
+		}
+	}
+	if canceled != nil {
+		return nil, canceled
+	}
+	g.scans = found
+	return found, nil
+}
+
+// within reports whether path is root or lies under it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (g *Generator) now() time.Time {
+	if g.Now == nil {
+		return time.Now()
+	}
+	return g.Now()
+}
+
+// generated returns the result of the last Generate, generating it first if
+// needed.
+func (g *Generator) generated() (*Result, error) {
+	if g.result != nil {
+		return g.result, nil
+	}
+	return g.Generate(context.Background())
+}
+
+// Files returns the sitemap files named name: a single sitemap, or an index
+// followed by its child sitemaps when the entries do not fit in one file. The
+// files are encoded when written with WriteSitemapFiles or their WriteTo.
+func (g *Generator) Files(name string) ([]SitemapFile, error) {
+	res, err := g.generated()
+	if err != nil {
+		return nil, err
+	}
+	dirURL := g.DirURL
+	if dirURL == "" {
+		dirURL = g.Base + "/"
+	}
+	return PlanSitemapFiles(dirURL, name, res.Entries)
+}
+
+// WriteTo streams the sitemap as a single <urlset> document to w. Use Files
+// for sites that need a sitemap index.
+func (g *Generator) WriteTo(w io.Writer) (int64, error) {
+	res, err := g.generated()
+	if err != nil {
+		return 0, err
+	}
+	return WriteURLSet(w, res.Entries)
+}
+
+// WriteNewsTo streams the Google News sitemap to w. It writes nothing when
+// news is not configured.
+func (g *Generator) WriteNewsTo(w io.Writer) (int64, error) {
+	if g.Config.News == nil {
+		return 0, nil
+	}
+	res, err := g.generated()
+	if err != nil {
+		return 0, err
+	}
+	return WriteNewsSitemap(w, g.Base, res.Found, *g.Config.News, g.now())
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,292 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gositemap/sitemap"
+)
+
+func TestGenerator(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "src", "routes", "about"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "routes", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(root, "src", "routes", "about", "+page.svelte"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(root, "src", "lib", "content"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "lib", "content", "hello.md"), []byte("---\ndate: 2024-01-01\n---\n"), 0644)
+
+	cfg := &sitemap.Config{BaseURL: "https://example.com/"}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	gen.AddSource(sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+		return []sitemap.Entry{{Loc: "/products/shoe", LastMod: day("2024-02-01")}}, nil
+	}))
+
+	res, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	var locs []string
+	for _, u := range res.Entries {
+		locs = append(locs, u.Loc)
+	}
+	want := "https://example.com/,https://example.com/about,https://example.com/blog/hello,https://example.com/products/shoe"
+	if strings.Join(locs, ",") != want {
+		t.Errorf("Expected %s, got %v", want, locs)
+	}
+	if res.Entries[2].ChangeFreq != "never" || res.Entries[2].LastModString() != "2024-01-01" {
+		t.Errorf("Unexpected article entry: %+v", res.Entries[2])
+	}
+
+	var buf bytes.Buffer
+	if _, err := gen.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<loc>https://example.com/products/shoe</loc>") {
+		t.Errorf("Expected custom source entry in output, got: %s", buf.String())
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	_, err := sitemap.NewGenerator(&sitemap.Config{BaseURL: "not-a-url"})
+	var configErr *sitemap.ConfigError
+	if !errors.As(err, &configErr) || configErr.Key != "base_url" {
+		t.Errorf("Expected base_url config error, got %v", err)
+	}
+
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "src", "routes"), 0755)
+	cfg := &sitemap.Config{BaseURL: "https://example.com"}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	gen.AddSource(sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+		return []sitemap.Entry{{Loc: "/partial"}}, &sitemap.ScanError{Path: "data", Err: errors.New("bad file")}
+	}))
+	res, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("A ScanError should not stop generation: %v", err)
+	}
+	if len(res.Warnings) != 1 || len(res.Entries) != 1 {
+		t.Errorf("Expected one warning and one URL, got %v and %+v", res.Warnings, res.Entries)
+	}
+
+	gen.AddSource(sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+		return nil, errors.New("boom")
+	}))
+	if _, err := gen.Generate(context.Background()); err == nil {
+		t.Error("Expected source error to stop generation")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := gen.Generate(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGenerator_GlobWarnings(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "src", "content", "docs")
+	os.MkdirAll(docs, 0755)
+	os.MkdirAll(filepath.Join(root, "src", "routes"), 0755)
+	os.WriteFile(filepath.Join(docs, "good.md"), []byte("---\ndate: 2024-01-01\n---\n"), 0644)
+	os.WriteFile(filepath.Join(docs, "bad.md"), []byte("---\ntitle: [oops\n---\n"), 0644)
+
+	cfg := &sitemap.Config{BaseURL: "https://example.com", Glob: []sitemap.Glob{{Paths: []string{"src/content/*"}}}}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	res, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("A bad content file should not stop generation: %v", err)
+	}
+	var locs []string
+	for _, e := range res.Entries {
+		locs = append(locs, e.Loc)
+	}
+	if !strings.Contains(strings.Join(locs, ","), "https://example.com/docs/good") {
+		t.Errorf("Expected the valid article to be kept, got %v", locs)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0].Error(), "bad.md") {
+		t.Errorf("Expected a warning naming bad.md, got %v", res.Warnings)
+	}
+}
+
+func TestGenerator_Parallel(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "src", "routes"), 0755)
+	cfg := &sitemap.Config{BaseURL: "https://example.com", Jobs: 4}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	gen.Sources = nil
+	for i := 0; i < 8; i++ {
+		loc := fmt.Sprintf("/page-%d", i)
+		delay := time.Duration(8-i) * time.Millisecond
+		gen.AddSource(sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+			time.Sleep(delay)
+			return []sitemap.Entry{{Loc: loc}}, nil
+		}))
+	}
+	res, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for i, e := range res.Found {
+		if want := fmt.Sprintf("/page-%d", i); e.Loc != want {
+			t.Errorf("Expected pages in source order, got %s at %d", e.Loc, i)
+		}
+	}
+
+	// A failing source cancels the sources still running.
+	boom := errors.New("boom")
+	gen.Sources = []sitemap.Source{
+		sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil, nil
+			}
+		}),
+		sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+			return nil, boom
+		}),
+	}
+	start := time.Now()
+	if _, err := gen.Generate(context.Background()); !errors.Is(err, boom) {
+		t.Errorf("Expected the source error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Expected the slow source to be cancelled")
+	}
+}
+
+// countingSource is a FileSource that counts its scans.
+type countingSource struct {
+	dir   string
+	loc   string
+	scans *int
+}
+
+func (s countingSource) Scan(ctx context.Context) ([]sitemap.Entry, error) {
+	*s.scans++
+	return []sitemap.Entry{{Loc: s.loc}}, nil
+}
+
+func (s countingSource) Paths() []string {
+	return []string{s.dir}
+}
+
+func TestGenerator_Regenerate(t *testing.T) {
+	root := t.TempDir()
+	cfg := &sitemap.Config{BaseURL: "https://example.com"}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	var blog, docs int
+	gen.Sources = []sitemap.Source{
+		countingSource{dir: filepath.Join(root, "blog"), loc: "/blog", scans: &blog},
+		countingSource{dir: filepath.Join(root, "docs"), loc: "/docs", scans: &docs},
+	}
+	if _, err := gen.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	res, err := gen.Regenerate(context.Background(), []string{filepath.Join(root, "docs", "intro.md")})
+	if err != nil {
+		t.Fatalf("Regenerate failed: %v", err)
+	}
+	if blog != 1 || docs != 2 {
+		t.Errorf("Expected only docs to be rescanned, got %d blog and %d docs scans", blog, docs)
+	}
+	if len(res.Entries) != 2 {
+		t.Errorf("Expected cached entries to be kept, got %+v", res.Entries)
+	}
+	if paths := gen.WatchPaths(); len(paths) != 2 || paths[1] != filepath.Join(root, "docs") {
+		t.Errorf("Unexpected watch paths: %v", paths)
+	}
+}
+
+func TestSources_Cancelled(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(root, "post.md"), []byte("---\ndate: 2024-01-01\n---\n"), 0644)
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sources := map[string]sitemap.Source{
+		"routes":  sitemap.RoutesSource{Dir: root},
+		"build":   sitemap.BuildSource{Dir: root},
+		"content": sitemap.ContentSource{Dir: root, Prefix: "blog"},
+		"glob":    sitemap.GlobSource{Patterns: []string{root}},
+	}
+	for name, s := range sources {
+		if _, err := s.Scan(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	crawl := sitemap.CrawlSource{Crawl: sitemap.Crawl{StartURL: srv.URL}}
+	if _, err := crawl.Scan(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("crawl: expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("crawl: expected requests to be aborted")
+	}
+}
+
+func TestGenerator_OtherHost(t *testing.T) {
+	root := t.TempDir()
+	cfg := &sitemap.Config{BaseURL: "https://example.com"}
+	cfg.ResolvePaths(root)
+	gen, err := sitemap.NewGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	gen.Sources = []sitemap.Source{sitemap.SourceFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+		return []sitemap.Entry{
+			{Loc: "/local"},
+			{Loc: "https://EXAMPLE.com/same"},
+			{Loc: "https://other.example.com/post", Sources: []string{"syndicated.md"}},
+		}, nil
+	})}
+	res, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(res.Entries) != 2 {
+		t.Errorf("Expected the entry on another host to be left out, got %+v", res.Entries)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0].Error(), "syndicated.md") {
+		t.Errorf("Expected a warning naming the source file, got %v", res.Warnings)
+	}
+}
@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LastMod sources.
+const (
+	// LastModSourceMtime uses the file modification time (the default).
+	LastModSourceMtime = "mtime"
+	// LastModSourceGit uses the date of the last commit touching the file.
+	LastModSourceGit = "git"
+)
+
+// GitHistory holds the date of the last commit touching each file of a git
+// repository. It is read once with a single git log, so looking up many
+// files does not walk the history again.
+type GitHistory struct {
+	root  string
+	dates map[string]time.Time
+	// Shallow is true for shallow clones, where files last changed before
+	// the clone depth get the date of the oldest fetched commit.
+	Shallow bool
+}
+
+// LoadGitHistory reads the history of the git repository holding dir. It only
+// reads the local repository.
+func LoadGitHistory(dir string) (*GitHistory, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(top))
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	shallow, err := git(root, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return nil, err
+	}
+	// Commits are listed newest first, each as a \x01<date> line followed by
+	// the files it touched.
+	out, err := git(root, "-c", "core.quotePath=off", "log", "--format=%x01%cI", "--name-only", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &GitHistory{
+		root:    root,
+		dates:   make(map[string]time.Time),
+		Shallow: strings.TrimSpace(string(shallow)) == "true",
+	}
+	var date time.Time
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "\x01") {
+			date, _ = time.Parse(time.RFC3339, line[1:])
+			continue
+		}
+		if line == "" || date.IsZero() {
+			continue
+		}
+		if _, ok := h.dates[line]; !ok {
+			h.dates[line] = date
+		}
+	}
+	return h, sc.Err()
+}
+
+// LastMod returns the date of the last commit touching path. ok is false for
+// files that were never committed.
+func (h *GitHistory) LastMod(path string) (time.Time, bool) {
+	if h == nil {
+		return time.Time{}, false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(h.root, abs)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t, ok := h.dates[filepath.ToSlash(rel)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return dateOf(t), true
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
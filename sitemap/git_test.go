@@ -0,0 +1,106 @@
+package sitemap_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+// gitCommit commits all changes in dir with the given commit date.
+func gitCommit(t *testing.T, dir, date string) {
+	t.Helper()
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "update"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	return dir
+}
+
+func TestGitHistory_LastMod(t *testing.T) {
+	dir := initGitRepo(t)
+	routes := filepath.Join(dir, "src", "routes")
+	os.MkdirAll(filepath.Join(routes, "about"), 0755)
+	os.MkdirAll(filepath.Join(routes, "contact"), 0755)
+	os.WriteFile(filepath.Join(routes, "+page.svelte"), []byte("home"), 0644)
+	os.WriteFile(filepath.Join(routes, "about", "+page.svelte"), []byte("about"), 0644)
+	gitCommit(t, dir, "2023-05-01T10:00:00Z")
+	os.WriteFile(filepath.Join(routes, "about", "+page.svelte"), []byte("about v2"), 0644)
+	gitCommit(t, dir, "2024-02-03T10:00:00Z")
+	// Never committed: falls back to the modification time.
+	os.WriteFile(filepath.Join(routes, "contact", "+page.svelte"), []byte("contact"), 0644)
+
+	history, err := sitemap.LoadGitHistory(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatalf("LoadGitHistory failed: %v", err)
+	}
+	if history.Shallow {
+		t.Error("Expected a full clone")
+	}
+
+	metas, err := sitemap.ScanRoutesWithOptions(routes, sitemap.RouteOptions{Git: history})
+	if err != nil {
+		t.Fatalf("ScanRoutesWithOptions failed: %v", err)
+	}
+	fi, _ := os.Stat(filepath.Join(routes, "contact", "+page.svelte"))
+	want := map[string]string{
+		"/":        "2023-05-01",
+		"/about":   "2024-02-03",
+		"/contact": fi.ModTime().Format("2006-01-02"),
+	}
+	for _, m := range metas {
+		if m.LastModString() != want[m.Loc] {
+			t.Errorf("%s: expected lastmod %s, got %s", m.Loc, want[m.Loc], m.LastMod)
+		}
+	}
+}
+
+func TestGitHistory_ContentFallbacks(t *testing.T) {
+	dir := initGitRepo(t)
+	os.WriteFile(filepath.Join(dir, "committed.md"), []byte("---\ndate: 2020-01-01\n---\n"), 0644)
+	gitCommit(t, dir, "2024-02-03T10:00:00Z")
+	os.WriteFile(filepath.Join(dir, "dated.md"), []byte("---\ndate: 2021-06-01\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "undated.md"), []byte("# Draft\n"), 0644)
+
+	history, err := sitemap.LoadGitHistory(dir)
+	if err != nil {
+		t.Fatalf("LoadGitHistory failed: %v", err)
+	}
+	metas, _ := sitemap.ScanContentWithOptions(dir, "blog", sitemap.ContentOptions{Git: history})
+	fi, _ := os.Stat(filepath.Join(dir, "undated.md"))
+	want := map[string]string{
+		"/blog/committed": "2024-02-03",
+		"/blog/dated":     "2021-06-01",
+		"/blog/undated":   fi.ModTime().Format("2006-01-02"),
+	}
+	if len(metas) != len(want) {
+		t.Fatalf("Expected %d articles, got %d", len(want), len(metas))
+	}
+	for _, m := range metas {
+		if m.LastModString() != want[m.Loc] {
+			t.Errorf("%s: expected lastmod %s, got %s", m.Loc, want[m.Loc], m.LastMod)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GzipExt is the extension of gzip-compressed sitemaps, e.g. sitemap.xml.gz.
+const GzipExt = ".gz"
+
+// IsGzipName reports whether a sitemap file name is gzip-compressed.
+func IsGzipName(name string) bool {
+	return strings.HasSuffix(name, GzipExt)
+}
+
+// WriteSitemapFiles writes files to dir. Files whose name ends in .gz are
+// gzip-compressed; their Data is always the uncompressed XML. Each file is
+// streamed to disk, compressed on the fly.
+func WriteSitemapFiles(dir string, files []SitemapFile) error {
+	for _, f := range files {
+		if err := writeSitemapFile(filepath.Join(dir, f.Name), f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveStaleSitemaps removes the files of previous, the sitemaps written to
+// dir by an earlier run, that files no longer holds. Missing files are
+// ignored. It returns the names of the files removed.
+func RemoveStaleSitemaps(dir string, previous []string, files []SitemapFile) ([]string, error) {
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		keep[f.Name] = true
+	}
+	var removed []string
+	for _, name := range previous {
+		if keep[name] {
+			continue
+		}
+		keep[name] = true
+		err := os.Remove(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+func writeSitemapFile(path string, f SitemapFile) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if !IsGzipName(f.Name) {
+		if _, err := f.WriteTo(out); err != nil {
+			return err
+		}
+		return out.Close()
+	}
+	zw, err := gzip.NewWriterLevel(out, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteTo(zw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// readSitemapFile reads a sitemap, decompressing it when it starts with the
+// gzip magic bytes, whatever its extension.
+func readSitemapFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
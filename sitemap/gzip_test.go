@@ -0,0 +1,141 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestWriteSitemapFiles_Gzip(t *testing.T) {
+	dir := t.TempDir()
+	urls := []sitemap.Entry{{Loc: "https://example.com/a", LastMod: day("2024-01-01"), Priority: "0.5"}}
+	files, err := sitemap.BuildSitemapFiles("https://example.com/", "sitemap.xml.gz", urls)
+	if err != nil {
+		t.Fatalf("BuildSitemapFiles failed: %v", err)
+	}
+	if err := sitemap.WriteSitemapFiles(dir, files); err != nil {
+		t.Fatalf("WriteSitemapFiles failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "sitemap.xml.gz"))
+	if err != nil {
+		t.Fatalf("Expected sitemap.xml.gz to be written: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected gzip data: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if !strings.Contains(string(plain), "<loc>https://example.com/a</loc>") {
+		t.Errorf("Unexpected decompressed sitemap: %s", plain)
+	}
+
+	loaded, err := sitemap.LoadSitemap(filepath.Join(dir, "sitemap.xml.gz"))
+	if err != nil {
+		t.Fatalf("LoadSitemap failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Loc != "https://example.com/a" || loaded[0].Priority != "0.5" {
+		t.Errorf("Expected entry to be read back, got %+v", loaded)
+	}
+}
+
+func TestLoadSitemap_GzipIndex(t *testing.T) {
+	dir := t.TempDir()
+	index := sitemap.SitemapFile{Name: "sitemap.xml.gz", Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-1.xml.gz</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-2.xml</loc></sitemap>
+</sitemapindex>`)}
+	child := func(name, loc string) sitemap.SitemapFile {
+		return sitemap.SitemapFile{Name: name, Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>` + loc + `</loc><lastmod>2020-01-01</lastmod></url>
+</urlset>`)}
+	}
+	files := []sitemap.SitemapFile{index, child("sitemap-1.xml.gz", "https://example.com/a"), child("sitemap-2.xml", "https://example.com/b")}
+	if err := sitemap.WriteSitemapFiles(dir, files); err != nil {
+		t.Fatalf("WriteSitemapFiles failed: %v", err)
+	}
+
+	urls, err := sitemap.LoadSitemap(filepath.Join(dir, "sitemap.xml.gz"))
+	if err != nil {
+		t.Fatalf("LoadSitemap failed: %v", err)
+	}
+	if len(urls) != 2 || urls[0].Loc != "https://example.com/a" || urls[1].Loc != "https://example.com/b" {
+		t.Errorf("Expected URLs from compressed and plain children, got %+v", urls)
+	}
+}
+
+func TestBuildSitemapFiles_GzipChildNames(t *testing.T) {
+	var urls []sitemap.Entry
+	for i := 0; i < sitemap.MaxURLsPerSitemap+1; i++ {
+		urls = append(urls, sitemap.Entry{Loc: fmt.Sprintf("https://example.com/page-%06d", i), LastMod: day("2024-01-01")})
+	}
+	files, err := sitemap.BuildSitemapFiles("https://example.com/", "sitemap.xml.gz", urls)
+	if err != nil {
+		t.Fatalf("BuildSitemapFiles failed: %v", err)
+	}
+	if len(files) != 3 || files[1].Name != "sitemap-1.xml.gz" || files[2].Name != "sitemap-2.xml.gz" {
+		t.Fatalf("Unexpected files: %d", len(files))
+	}
+	if !strings.Contains(string(files[0].Data), "<loc>https://example.com/sitemap-1.xml.gz</loc>") {
+		t.Errorf("Index should point to compressed children: %s", files[0].Data)
+	}
+}
+
+func TestRemoveStaleSitemaps(t *testing.T) {
+	dir := t.TempDir()
+	var urls []sitemap.Entry
+	for i := 0; i < sitemap.MaxURLsPerSitemap+1; i++ {
+		urls = append(urls, sitemap.Entry{Loc: fmt.Sprintf("https://example.com/page-%06d", i)})
+	}
+	index, err := sitemap.PlanSitemapFiles("https://example.com/", "sitemap.xml", urls)
+	if err != nil {
+		t.Fatalf("PlanSitemapFiles failed: %v", err)
+	}
+	if err := sitemap.WriteSitemapFiles(dir, index); err != nil {
+		t.Fatalf("WriteSitemapFiles failed: %v", err)
+	}
+	// Files gositemap did not write are left alone.
+	os.WriteFile(filepath.Join(dir, "sitemap-2023.xml"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "sitemap.xml.gz"), nil, 0644)
+
+	previous, err := sitemap.SitemapChildren(filepath.Join(dir, "sitemap.xml"), "https://example.com")
+	if err != nil {
+		t.Fatalf("SitemapChildren failed: %v", err)
+	}
+	if got := strings.Join(previous, ","); got != "sitemap-1.xml,sitemap-2.xml" {
+		t.Errorf("Unexpected children: %s", got)
+	}
+
+	// The entries now fit a single sitemap.
+	single, err := sitemap.PlanSitemapFiles("https://example.com/", "sitemap.xml", urls[:1])
+	if err != nil {
+		t.Fatalf("PlanSitemapFiles failed: %v", err)
+	}
+	if err := sitemap.WriteSitemapFiles(dir, single); err != nil {
+		t.Fatalf("WriteSitemapFiles failed: %v", err)
+	}
+	removed, err := sitemap.RemoveStaleSitemaps(dir, previous, single)
+	if err != nil {
+		t.Fatalf("RemoveStaleSitemaps failed: %v", err)
+	}
+	if got := strings.Join(removed, ","); got != "sitemap-1.xml,sitemap-2.xml" {
+		t.Errorf("Unexpected removed files: %s", got)
+	}
+	left, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range left {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, ","); got != "sitemap-2023.xml,sitemap.xml,sitemap.xml.gz" {
+		t.Errorf("Expected the new sitemap and unrelated files to be left, got %s", got)
+	}
+}
@@ -0,0 +1,214 @@
+package sitemap
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const xhtmlNS = "http://www.w3.org/1999/xhtml"
+
+// Locale URL prefix strategies.
+const (
+	// PrefixExceptDefault serves the default locale without a prefix
+	// (/about) and the others under /<locale>/ (/fr/about).
+	PrefixExceptDefault = "except-default"
+	// PrefixAlways serves every locale under /<locale>/.
+	PrefixAlways = "always"
+)
+
+// I18n configures multilingual sitemaps.
+type I18n struct {
+	Locales       []string `toml:"locales"`
+	DefaultLocale string   `toml:"default_locale"`
+	// Prefix is the URL prefix strategy: "except-default" (default) or "always".
+	Prefix string `toml:"prefix"`
+	// Param is the route parameter holding the locale, "lang" by default.
+	Param string `toml:"param"`
+	// Content maps a content type to its directory for each locale.
+	Content map[string]map[string]string `toml:"content"`
+}
+
+// Alternate is an <xhtml:link rel="alternate" hreflang="..."> entry.
+type Alternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Validate checks the locales and the prefix strategy.
+func (c I18n) Validate() error {
+	if len(c.Locales) == 0 {
+		return fmt.Errorf("i18n: locales must not be empty")
+	}
+	if c.DefaultLocale != "" && !slices.Contains(c.Locales, c.DefaultLocale) {
+		return fmt.Errorf("i18n: default_locale %q is not in locales", c.DefaultLocale)
+	}
+	switch c.Prefix {
+	case "", PrefixExceptDefault, PrefixAlways:
+	default:
+		return fmt.Errorf("i18n: invalid prefix %q (must be %q or %q)", c.Prefix, PrefixExceptDefault, PrefixAlways)
+	}
+	return nil
+}
+
+func (c I18n) defaultLocale() string {
+	if c.DefaultLocale != "" {
+		return c.DefaultLocale
+	}
+	if len(c.Locales) > 0 {
+		return c.Locales[0]
+	}
+	return ""
+}
+
+func (c I18n) param() string {
+	if c.Param != "" {
+		return c.Param
+	}
+	return "lang"
+}
+
+// URLPrefix returns the path prefix of a locale, e.g. "/fr", or "" for the
+// default locale with the except-default strategy.
+func (c I18n) URLPrefix(locale string) string {
+	if c.Prefix != PrefixAlways && locale == c.defaultLocale() {
+		return ""
+	}
+	return "/" + locale
+}
+
+// RouteParams returns the values of the locale route parameter, to expand
+// routes such as /[[lang]]/about for every locale.
+func (c I18n) RouteParams() map[string][]string {
+	var values []string
+	for _, l := range c.Locales {
+		values = append(values, strings.TrimPrefix(c.URLPrefix(l), "/"))
+	}
+	return map[string][]string{c.param(): values}
+}
+
+// splitLocale returns the locale of a page path and the path without its
+// locale prefix. ok is false for paths that do not belong to a locale.
+func (c I18n) splitLocale(path string) (locale, key string, ok bool) {
+	trimmed := strings.TrimPrefix(path, "/")
+	first, rest, _ := strings.Cut(trimmed, "/")
+	for _, l := range c.Locales {
+		if first == l && c.URLPrefix(l) != "" {
+			return l, "/" + rest, true
+		}
+	}
+	if c.Prefix == PrefixAlways {
+		return "", "", false
+	}
+	return c.defaultLocale(), "/" + trimmed, true
+}
+
+// ApplyAlternates groups the URLs that are translations of the same page and
+// sets their hreflang alternates: one per locale of the group, plus an
+// x-default pointing at the default locale. Pages without translations get no
+// alternates.
+func ApplyAlternates(urls []Entry, base string, c I18n) []Entry {
+	base = strings.TrimRight(base, "/")
+	type member struct {
+		index  int
+		locale string
+	}
+	groups := make(map[string][]member)
+	var keys []string
+	for i := range urls {
+		urls[i].Alternates = nil
+		if !strings.HasPrefix(urls[i].Loc, base+"/") && urls[i].Loc != base {
+			continue
+		}
+		locale, key, ok := c.splitLocale(strings.TrimPrefix(urls[i].Loc, base))
+		if !ok {
+			continue
+		}
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], member{i, locale})
+	}
+
+	order := func(locale string) int { return slices.Index(c.Locales, locale) }
+	for _, key := range keys {
+		members := groups[key]
+		if len(members) < 2 {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool { return order(members[i].locale) < order(members[j].locale) })
+
+		var alternates []Alternate
+		xDefault := ""
+		for _, m := range members {
+			alternates = append(alternates, Alternate{Rel: "alternate", Hreflang: m.locale, Href: urls[m.index].Loc})
+			if m.locale == c.defaultLocale() {
+				xDefault = urls[m.index].Loc
+			}
+		}
+		if xDefault != "" {
+			alternates = append(alternates, Alternate{Rel: "alternate", Hreflang: "x-default", Href: xDefault})
+		}
+		for _, m := range members {
+			urls[m.index].Alternates = alternates
+		}
+	}
+	return urls
+}
+
+// ValidateAlternates checks that every alternate cluster is reciprocal: each
+// alternate must be in the sitemap and list the page back with the same
+// hreflang, and a cluster must not repeat a hreflang.
+func ValidateAlternates(urls []Entry) []error {
+	byLoc := make(map[string]Entry, len(urls))
+	for _, u := range urls {
+		byLoc[u.Loc] = u
+	}
+
+	var errs []error
+	for _, u := range urls {
+		if len(u.Alternates) == 0 {
+			continue
+		}
+		self := ""
+		seen := make(map[string]string)
+		for _, a := range u.Alternates {
+			if prev, ok := seen[a.Hreflang]; ok && prev != a.Href {
+				errs = append(errs, fmt.Errorf("%s: hreflang %q points to both %s and %s", u.Loc, a.Hreflang, prev, a.Href))
+			}
+			seen[a.Hreflang] = a.Href
+			if a.Href == u.Loc && a.Hreflang != "x-default" {
+				self = a.Hreflang
+			}
+		}
+		if self == "" {
+			errs = append(errs, fmt.Errorf("%s: alternates do not include the page itself", u.Loc))
+			continue
+		}
+		for _, a := range u.Alternates {
+			if a.Href == u.Loc {
+				continue
+			}
+			other, ok := byLoc[a.Href]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: alternate %s (%s) is not in the sitemap", u.Loc, a.Href, a.Hreflang))
+				continue
+			}
+			if !hasAlternate(other.Alternates, self, u.Loc) {
+				errs = append(errs, fmt.Errorf("%s: alternate %s (%s) does not link back with hreflang %q", u.Loc, a.Href, a.Hreflang, self))
+			}
+		}
+	}
+	return errs
+}
+
+func hasAlternate(alternates []Alternate, hreflang, href string) bool {
+	for _, a := range alternates {
+		if a.Hreflang == hreflang && a.Href == href {
+			return true
+		}
+	}
+	return false
+}
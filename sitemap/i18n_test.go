@@ -0,0 +1,135 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestI18n_RoutesAndAlternates(t *testing.T) {
+	i18n := sitemap.I18n{Locales: []string{"en", "fr"}, DefaultLocale: "en"}
+	if err := i18n.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	routes := t.TempDir()
+	os.MkdirAll(filepath.Join(routes, "[[lang]]", "about"), 0755)
+	os.WriteFile(filepath.Join(routes, "[[lang]]", "+page.svelte"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(routes, "[[lang]]", "about", "+page.svelte"), []byte(""), 0644)
+
+	metas, err := sitemap.ScanRoutesWithOptions(routes, sitemap.RouteOptions{Params: i18n.RouteParams()})
+	if err != nil {
+		t.Fatalf("ScanRoutesWithOptions failed: %v", err)
+	}
+	got := map[string]bool{}
+	for _, m := range metas {
+		got[m.Loc] = true
+	}
+	for _, want := range []string{"/", "/fr", "/about", "/fr/about"} {
+		if !got[want] {
+			t.Errorf("Missing localized route %s in %+v", want, metas)
+		}
+	}
+
+	content := t.TempDir()
+	os.MkdirAll(filepath.Join(content, "en"), 0755)
+	os.MkdirAll(filepath.Join(content, "fr"), 0755)
+	os.WriteFile(filepath.Join(content, "en", "hello.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(content, "fr", "hello.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(content, "fr", "bonjour.md"), []byte(""), 0644)
+	var articles []sitemap.Entry
+	for _, locale := range i18n.Locales {
+		prefix := strings.TrimPrefix(i18n.URLPrefix(locale)+"/blog", "/")
+		m, _ := sitemap.ScanContent(filepath.Join(content, locale), prefix, "never")
+		articles = append(articles, m...)
+	}
+
+	urls := sitemap.MergeEntries("https://example.com", append(metas, articles...), nil, false)
+	urls = sitemap.ApplyAlternates(urls, "https://example.com", i18n)
+	if errs := sitemap.ValidateAlternates(urls); len(errs) != 0 {
+		t.Errorf("Expected reciprocal alternates, got %v", errs)
+	}
+
+	byLoc := map[string]sitemap.Entry{}
+	for _, u := range urls {
+		byLoc[u.Loc] = u
+	}
+	fr := byLoc["https://example.com/fr/blog/hello"]
+	if len(fr.Alternates) != 3 {
+		t.Fatalf("Expected en, fr and x-default alternates, got %+v", fr.Alternates)
+	}
+	if fr.Alternates[0].Hreflang != "en" || fr.Alternates[0].Href != "https://example.com/blog/hello" ||
+		fr.Alternates[2].Hreflang != "x-default" || fr.Alternates[2].Href != "https://example.com/blog/hello" {
+		t.Errorf("Unexpected alternates: %+v", fr.Alternates)
+	}
+	if len(byLoc["https://example.com/fr/blog/bonjour"].Alternates) != 0 {
+		t.Errorf("Untranslated page should have no alternates")
+	}
+	if len(byLoc["https://example.com/fr"].Alternates) != 3 {
+		t.Errorf("Expected home page alternates, got %+v", byLoc["https://example.com/fr"].Alternates)
+	}
+
+	xml := sitemap.GenerateSitemap("https://example.com", nil, urls, false)
+	if !strings.Contains(xml, `xmlns:xhtml="http://www.w3.org/1999/xhtml"`) ||
+		!strings.Contains(xml, `<xhtml:link rel="alternate" hreflang="fr" href="https://example.com/fr/about"></xhtml:link>`) {
+		t.Errorf("Missing xhtml alternates in sitemap: %s", xml)
+	}
+}
+
+func TestI18n_PrefixAlways(t *testing.T) {
+	i18n := sitemap.I18n{Locales: []string{"en", "fr"}, DefaultLocale: "en", Prefix: sitemap.PrefixAlways}
+	if i18n.URLPrefix("en") != "/en" {
+		t.Errorf("Expected /en prefix, got %q", i18n.URLPrefix("en"))
+	}
+	urls := []sitemap.Entry{
+		{Loc: "https://example.com/en/about"},
+		{Loc: "https://example.com/fr/about"},
+		{Loc: "https://example.com/about"},
+	}
+	urls = sitemap.ApplyAlternates(urls, "https://example.com", i18n)
+	if len(urls[0].Alternates) != 3 || len(urls[2].Alternates) != 0 {
+		t.Errorf("Unexpected alternates: %+v", urls)
+	}
+
+	if err := (sitemap.I18n{Locales: []string{"en"}, Prefix: "sometimes"}).Validate(); err == nil {
+		t.Error("Expected an error for an invalid prefix")
+	}
+}
+
+func TestValidateAlternates_NotReciprocal(t *testing.T) {
+	urls := []sitemap.Entry{
+		{Loc: "https://example.com/a", Alternates: []sitemap.Alternate{
+			{Rel: "alternate", Hreflang: "en", Href: "https://example.com/a"},
+			{Rel: "alternate", Hreflang: "fr", Href: "https://example.com/fr/a"},
+		}},
+		{Loc: "https://example.com/fr/a", Alternates: []sitemap.Alternate{
+			{Rel: "alternate", Hreflang: "fr", Href: "https://example.com/fr/a"},
+		}},
+	}
+	errs := sitemap.ValidateAlternates(urls)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "does not link back") {
+		t.Errorf("Expected a reciprocity error, got %v", errs)
+	}
+}
+
+func TestI18n_RequiredLangExceptDefault(t *testing.T) {
+	i18n := sitemap.I18n{Locales: []string{"en", "fr"}, DefaultLocale: "en"}
+	routes := t.TempDir()
+	os.MkdirAll(filepath.Join(routes, "[lang]", "about"), 0755)
+	os.WriteFile(filepath.Join(routes, "[lang]", "about", "+page.svelte"), []byte(""), 0644)
+
+	metas, err := sitemap.ScanRoutesWithOptions(routes, sitemap.RouteOptions{Params: i18n.RouteParams()})
+	if err != nil {
+		t.Fatalf("ScanRoutesWithOptions failed: %v", err)
+	}
+	var locs []string
+	for _, m := range metas {
+		locs = append(locs, m.Loc)
+	}
+	if strings.Join(locs, ",") != "/about,/fr/about" {
+		t.Errorf("Expected the default locale without prefix, got %v", locs)
+	}
+}
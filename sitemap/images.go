@@ -0,0 +1,137 @@
+package sitemap
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxImagesPerURL is the maximum number of <image:image> entries per <url>.
+const MaxImagesPerURL = 1000
+
+var (
+	// ![alt](src "title") and ![alt](<src>)
+	markdownImageRe = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^\s)>]+)>?(?:\s+["'][^"']*["'])?\s*\)`)
+	// <img ... src="...">
+	htmlImageRe = regexp.MustCompile(`(?i)<img\b[^>]*?\ssrc\s*=\s*["']([^"']+)["']`)
+)
+
+// Image is an <image:image> entry of the Google image sitemap extension.
+type Image struct {
+	Loc string `xml:"image:loc"`
+}
+
+// frontMatterImages returns the images listed under cover, image and images.
+func frontMatterImages(fm FrontMatter) []string {
+	var images []string
+	for _, key := range []string{"cover", "image", "images"} {
+		images = append(images, imageRefs(fm[key])...)
+	}
+	return images
+}
+
+// imageRefs reads an image reference given as a string, a {src: ...} or
+// {url: ...} object, or a list of those.
+func imageRefs(v any) []string {
+	switch v := v.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			return []string{s}
+		}
+	case []any:
+		var refs []string
+		for _, item := range v {
+			refs = append(refs, imageRefs(item)...)
+		}
+		return refs
+	case FrontMatter:
+		return imageRefs(map[string]any(v))
+	case map[string]any:
+		for _, key := range []string{"src", "url", "loc"} {
+			if s, ok := v[key].(string); ok {
+				return imageRefs(s)
+			}
+		}
+	}
+	return nil
+}
+
+// fileImages returns the images of a page file: frontmatter images followed by
+// the Markdown and HTML images of its body.
+func fileImages(path string) []string {
+	fm, body, _ := parseContentFile(path)
+	if fm == nil {
+		return nil
+	}
+	return append(frontMatterImages(fm), bodyImages(body)...)
+}
+
+// bodyImages returns the Markdown and HTML images referenced in body, in
+// order of appearance.
+func bodyImages(body []byte) []string {
+	type match struct {
+		pos int
+		src string
+	}
+	var matches []match
+	for _, re := range []*regexp.Regexp{markdownImageRe, htmlImageRe} {
+		for _, m := range re.FindAllSubmatchIndex(body, -1) {
+			matches = append(matches, match{m[2], string(body[m[2]:m[3]])})
+		}
+	}
+	// Keep document order across both kinds of references
+	sort.Slice(matches, func(i, j int) bool { return matches[i].pos < matches[j].pos })
+	images := make([]string, 0, len(matches))
+	for _, m := range matches {
+		images = append(images, m.src)
+	}
+	return images
+}
+
+// resolveImages turns image references into absolute, unique URLs. Dynamic
+// (Svelte expressions) and data: references are dropped.
+func resolveImages(base, loc string, refs []Image) []Image {
+	if len(refs) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var images []Image
+	for _, ref := range refs {
+		abs, ok := resolveRef(base, loc, ref.Loc)
+		if !ok || seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		images = append(images, Image{Loc: abs})
+		if len(images) == MaxImagesPerURL {
+			break
+		}
+	}
+	return images
+}
+
+// resolveRef resolves a reference found in a page: root relative references
+// against base, other relative references against the page loc. ok is false
+// for references that cannot be resolved statically.
+func resolveRef(base, loc, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.ContainsAny(ref, "{}") || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+	switch {
+	case strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://"):
+		return ref, true
+	case strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "//"):
+		return strings.TrimRight(base, "/") + ref, true
+	}
+	page, err := url.Parse(loc)
+	if err != nil {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	return page.ResolveReference(u).String(), true
+}
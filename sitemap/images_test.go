@@ -0,0 +1,85 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestScanContent_Images(t *testing.T) {
+	dir := t.TempDir()
+	post := `---
+cover: /images/cover.jpg
+images:
+  - https://cdn.example.com/a.png
+  - src: ./b.png
+---
+Intro ![diagram](diagram.svg "Diagram") and <img class="x" src="/images/inline.webp">.
+Also ![dup](/images/cover.jpg) and <img src={dynamic}> and ![inline](data:image/png;base64,AAA).
+`
+	os.WriteFile(filepath.Join(dir, "post.md"), []byte(post), 0644)
+
+	metas, err := sitemap.ScanContent(dir, "blog", "never")
+	if err != nil || len(metas) != 1 {
+		t.Fatalf("ScanContent failed: %v %+v", err, metas)
+	}
+
+	xml := sitemap.GenerateSitemap("https://example.com", metas, nil, false)
+	if !strings.Contains(xml, `xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"`) {
+		t.Errorf("Missing image namespace: %s", xml)
+	}
+	want := []string{
+		"https://example.com/images/cover.jpg",
+		"https://cdn.example.com/a.png",
+		"https://example.com/blog/b.png",
+		"https://example.com/blog/diagram.svg",
+		"https://example.com/images/inline.webp",
+	}
+	last := -1
+	for _, w := range want {
+		pos := strings.Index(xml, "<image:loc>"+w+"</image:loc>")
+		if pos == -1 {
+			t.Errorf("Missing image %s: %s", w, xml)
+		} else if pos < last {
+			t.Errorf("Image %s out of order", w)
+		}
+		last = pos
+	}
+	if count := strings.Count(xml, "<image:image>"); count != len(want) {
+		t.Errorf("Expected %d unique images, got %d: %s", len(want), count, xml)
+	}
+}
+
+func TestScanRoutes_Images(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "gallery"), 0755)
+	os.WriteFile(filepath.Join(dir, "gallery", "+page.svelte"), []byte(`<img src="/photos/1.jpg" alt="" />`), 0644)
+	os.WriteFile(filepath.Join(dir, "+page.svelte"), []byte(`<h1>Home</h1>`), 0644)
+
+	routes, err := sitemap.ScanRoutes(dir, nil)
+	if err != nil {
+		t.Fatalf("ScanRoutes failed: %v", err)
+	}
+	xml := sitemap.GenerateSitemap("https://example.com", routes, nil, false)
+	if !strings.Contains(xml, "<loc>https://example.com/gallery</loc>") || !strings.Contains(xml, "<image:loc>https://example.com/photos/1.jpg</image:loc>") {
+		t.Errorf("Missing page image: %s", xml)
+	}
+}
+
+func TestLoadSitemap_PreservesImages(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sitemap.xml")
+	content := []sitemap.Entry{{Loc: "/blog/a", LastMod: day("2020-01-01"), Images: []sitemap.Image{{Loc: "/a.png"}}, Origin: sitemap.OriginContent}}
+	os.WriteFile(path, []byte(sitemap.GenerateSitemap("https://example.com", content, nil, false)), 0644)
+
+	urls, err := sitemap.LoadSitemap(path)
+	if err != nil {
+		t.Fatalf("LoadSitemap failed: %v", err)
+	}
+	if len(urls) != 1 || len(urls[0].Images) != 1 || urls[0].Images[0].Loc != "https://example.com/a.png" {
+		t.Errorf("Expected image to be read back, got %+v", urls)
+	}
+}
@@ -0,0 +1,142 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	newsNS = "http://www.google.com/schemas/sitemap-news/0.9"
+
+	// NewsMaxAge is how long an article stays in the news sitemap.
+	NewsMaxAge = 48 * time.Hour
+	// MaxNewsURLs is the maximum number of <url> entries in a news sitemap.
+	MaxNewsURLs = 1000
+)
+
+// News configures the Google News sitemap.
+type News struct {
+	// Publication is the name of the news publication.
+	Publication string `toml:"publication"`
+	// Language is the ISO 639 code of the publication, e.g. "fr".
+	Language string `toml:"language"`
+	// OutputPath is where the news sitemap is written, by default
+	// news-sitemap.xml next to the main sitemap.
+	OutputPath string `toml:"output_path"`
+	// ContentTypes limits the news sitemap to these content types. All
+	// content types are used when empty.
+	ContentTypes []string `toml:"content_types"`
+}
+
+type newsURL struct {
+	Loc  string    `xml:"loc"`
+	News newsEntry `xml:"news:news"`
+}
+
+type newsEntry struct {
+	Publication     newsPublication `xml:"news:publication"`
+	PublicationDate string          `xml:"news:publication_date"`
+	Title           string          `xml:"news:title"`
+	Keywords        string          `xml:"news:keywords,omitempty"`
+}
+
+type newsPublication struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+// Validate checks that the publication name and language are set.
+func (n News) Validate() error {
+	if strings.TrimSpace(n.Publication) == "" {
+		return fmt.Errorf("news: publication must be set")
+	}
+	if strings.TrimSpace(n.Language) == "" {
+		return fmt.Errorf("news: language must be set")
+	}
+	return nil
+}
+
+// NewsContent returns the articles eligible for the news sitemap: published
+// within NewsMaxAge before now, with a title, and of one of the configured
+// content types. The most recent articles come first.
+func NewsContent(content []Entry, n News, now time.Time) []Entry {
+	var recent []Entry
+	for _, c := range content {
+		if !c.IsArticle() {
+			continue
+		}
+		if len(n.ContentTypes) > 0 && !slices.Contains(n.ContentTypes, c.Type) {
+			continue
+		}
+		if c.Title == "" || c.Published.IsZero() {
+			continue
+		}
+		if c.Published.After(now) || now.Sub(c.Published) > NewsMaxAge {
+			continue
+		}
+		recent = append(recent, c)
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		if !recent[i].Published.Equal(recent[j].Published) {
+			return recent[i].Published.After(recent[j].Published)
+		}
+		return recent[i].Loc < recent[j].Loc
+	})
+	if len(recent) > MaxNewsURLs {
+		recent = recent[:MaxNewsURLs]
+	}
+	return recent
+}
+
+// GenerateNewsSitemap generates a Google News sitemap holding the articles
+// published within the last 48 hours before now.
+func GenerateNewsSitemap(base string, content []Entry, n News, now time.Time) string {
+	var buf bytes.Buffer
+	if _, err := WriteNewsSitemap(&buf, base, content, n, now); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// WriteNewsSitemap streams the news sitemap of GenerateNewsSitemap to w one
+// article at a time.
+func WriteNewsSitemap(w io.Writer, base string, content []Entry, n News, now time.Time) (int64, error) {
+	cw := &countingWriter{w: w}
+	if _, err := io.WriteString(cw, xml.Header); err != nil {
+		return cw.n, err
+	}
+	enc := xml.NewEncoder(cw)
+	enc.Indent("", "  ")
+	urlset := xml.StartElement{Name: xml.Name{Local: "urlset"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "xmlns"}, Value: sitemapNS},
+		{Name: xml.Name{Local: "xmlns:news"}, Value: newsNS},
+	}}
+	if err := enc.EncodeToken(urlset); err != nil {
+		return cw.n, err
+	}
+	for _, c := range NewsContent(content, n, now) {
+		u := newsURL{
+			Loc: absoluteLoc(base, c.Loc),
+			News: newsEntry{
+				Publication:     newsPublication{Name: n.Publication, Language: n.Language},
+				PublicationDate: formatLastMod(c.Published),
+				Title:           c.Title,
+				Keywords:        strings.Join(c.Keywords, ", "),
+			},
+		}
+		if err := enc.EncodeElement(u, xml.StartElement{Name: xml.Name{Local: "url"}}); err != nil {
+			return cw.n, err
+		}
+	}
+	if err := enc.EncodeToken(urlset.End()); err != nil {
+		return cw.n, err
+	}
+	err := enc.Flush()
+	return cw.n, err
+}
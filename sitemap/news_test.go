@@ -0,0 +1,64 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gositemap/sitemap"
+)
+
+func TestGenerateNewsSitemap(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "fresh.md"), []byte("---\ntitle: \"Breaking: it works\"\npublishDate: 2024-05-10T08:00:00Z\nkeywords: [go, sitemap]\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "yesterday.md"), []byte("---\ntitle: Yesterday\ndate: 2024-05-09T10:00:00+02:00\ntags: go, news\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte("---\ntitle: Old\npublishDate: 2024-05-01\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "future.md"), []byte("---\ntitle: Future\npublishDate: 2024-05-11\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "untitled.md"), []byte("---\npublishDate: 2024-05-10\n---\n"), 0644)
+
+	metas, err := sitemap.ScanContentWithOptions(dir, "news", sitemap.ContentOptions{Type: "news"})
+	if err != nil {
+		t.Fatalf("ScanContentWithOptions failed: %v", err)
+	}
+	news := sitemap.News{Publication: "Le Lab", Language: "fr"}
+	xml := sitemap.GenerateNewsSitemap("https://example.com", metas, news, now)
+
+	if !strings.Contains(xml, `xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"`) {
+		t.Errorf("Missing news namespace: %s", xml)
+	}
+	if count := strings.Count(xml, "<url>"); count != 2 {
+		t.Errorf("Expected 2 recent articles, got %d: %s", count, xml)
+	}
+	for _, want := range []string{
+		"<news:name>Le Lab</news:name>",
+		"<news:language>fr</news:language>",
+		"<news:title>Breaking: it works</news:title>",
+		"<news:publication_date>2024-05-10T08:00:00Z</news:publication_date>",
+		"<news:keywords>go, sitemap</news:keywords>",
+		"<news:keywords>go, news</news:keywords>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("Missing %s in news sitemap: %s", want, xml)
+		}
+	}
+	if strings.Index(xml, "/news/fresh") > strings.Index(xml, "/news/yesterday") {
+		t.Errorf("Expected most recent article first: %s", xml)
+	}
+
+	news.ContentTypes = []string{"blog"}
+	if xml := sitemap.GenerateNewsSitemap("https://example.com", metas, news, now); strings.Contains(xml, "<url>") {
+		t.Errorf("Expected content types to filter articles: %s", xml)
+	}
+}
+
+func TestNewsValidate(t *testing.T) {
+	if err := (sitemap.News{Publication: "Le Lab"}).Validate(); err == nil {
+		t.Error("Expected an error when language is missing")
+	}
+	if err := (sitemap.News{Publication: "Le Lab", Language: "fr"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
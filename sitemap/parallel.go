@@ -0,0 +1,41 @@
+package sitemap
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach calls fn for every index in [0, n) from at most jobs goroutines, and
+// waits for them to return. No new call starts once ctx is done. Callers store
+// results by index so that the output does not depend on scheduling.
+func forEach(ctx context.Context, jobs, n int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > n {
+		jobs = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() == nil {
+					fn(i)
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
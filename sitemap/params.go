@@ -0,0 +1,200 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// paramRe matches SvelteKit route parameters: [id], [[lang]], [...path] and
+// parameters with a matcher such as [id=integer].
+var paramRe = regexp.MustCompile(`\[\[?(\.\.\.)?([^\]=]+)(=[^\]]+)?\]?\]`)
+
+// routeParam describes one parameter found in a route pattern.
+type routeParam struct {
+	Name     string
+	Optional bool
+	Rest     bool
+}
+
+// hasParams reports whether a route path contains a [param] segment.
+func hasParams(route string) bool {
+	return paramRe.MatchString(route)
+}
+
+// parseParams returns the parameters of a route pattern in order of appearance.
+func parseParams(pattern string) []routeParam {
+	var params []routeParam
+	for _, m := range paramRe.FindAllStringSubmatch(pattern, -1) {
+		params = append(params, routeParam{
+			Name:     m[2],
+			Optional: strings.HasPrefix(m[0], "[["),
+			Rest:     m[1] != "",
+		})
+	}
+	return params
+}
+
+// normalizeRoute turns a route path or configured pattern into a comparable
+// form: leading slash, no trailing slash and no (group) segments.
+func normalizeRoute(route string) string {
+	var clean []string
+	for _, part := range strings.Split(strings.ReplaceAll(route, "\\", "/"), "/") {
+		if part == "" || (strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")")) {
+			continue
+		}
+		clean = append(clean, part)
+	}
+	return "/" + strings.Join(clean, "/")
+}
+
+// expandRoute fills the parameters of a route with values. Optional and rest
+// segments with an empty value are dropped from the URL, as are those of the
+// parameters with global values in params.
+func expandRoute(route string, values map[string]string, params map[string][]string) (string, error) {
+	var out []string
+	for _, part := range strings.Split(normalizeRoute(route), "/") {
+		if part == "" {
+			continue
+		}
+		var missing string
+		expanded := paramRe.ReplaceAllStringFunc(part, func(m string) string {
+			p := parseParams(m)[0]
+			v := values[p.Name]
+			if _, global := params[p.Name]; v == "" && !p.Optional && !p.Rest && !global {
+				missing = p.Name
+			}
+			return v
+		})
+		if missing != "" {
+			return "", fmt.Errorf("no value for parameter %q in %s", missing, route)
+		}
+		if expanded == "" {
+			continue
+		}
+		out = append(out, strings.Trim(expanded, "/"))
+	}
+	return "/" + strings.Join(out, "/"), nil
+}
+
+// ParamSets loads every parameter set configured for the dynamic route, from the
+// inline values, the data file and the glob, in that order.
+func (d DynamicRoute) ParamSets() ([]map[string]string, error) {
+	param := d.Param
+	if param == "" {
+		params := parseParams(d.Pattern)
+		if len(params) == 0 {
+			return nil, fmt.Errorf("dynamic route %q has no [param] segment", d.Pattern)
+		}
+		param = params[len(params)-1].Name
+	}
+
+	sets, err := paramSets(d.Values, param)
+	if err != nil {
+		return nil, fmt.Errorf("dynamic route %q: %w", d.Pattern, err)
+	}
+
+	if d.File != "" {
+		items, err := loadParamFile(d.File)
+		if err != nil {
+			return nil, fmt.Errorf("dynamic route %q: %w", d.Pattern, err)
+		}
+		fileSets, err := paramSets(items, param)
+		if err != nil {
+			return nil, fmt.Errorf("dynamic route %q: %s: %w", d.Pattern, d.File, err)
+		}
+		sets = append(sets, fileSets...)
+	}
+
+	if d.Glob != "" {
+		matches, err := filepath.Glob(d.Glob)
+		if err != nil {
+			return nil, fmt.Errorf("dynamic route %q: %w", d.Pattern, err)
+		}
+		for _, m := range matches {
+			base := filepath.Base(m)
+			sets = append(sets, map[string]string{param: strings.TrimSuffix(base, filepath.Ext(base))})
+		}
+	}
+	return sets, nil
+}
+
+// paramSets converts raw values into parameter sets. A plain value is bound to
+// param, a map binds each of its keys.
+func paramSets(items []any, param string) ([]map[string]string, error) {
+	var sets []map[string]string
+	for _, item := range items {
+		switch v := item.(type) {
+		case map[string]any:
+			set := make(map[string]string, len(v))
+			for k, val := range v {
+				set[k] = paramValue(val)
+			}
+			sets = append(sets, set)
+		case map[string]string:
+			sets = append(sets, v)
+		case []any, nil:
+			return nil, fmt.Errorf("unsupported parameter value %v", v)
+		default:
+			sets = append(sets, map[string]string{param: paramValue(v)})
+		}
+	}
+	return sets, nil
+}
+
+// paramValue formats a parameter value. Floats are written without exponent,
+// so an ID of 1234567 read as a float stays 1234567.
+func paramValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// loadParamFile reads parameter values from a JSON, YAML or CSV file. JSON and
+// YAML files hold a list of values or of objects; CSV files have a header row
+// naming the parameters.
+func loadParamFile(path string) ([]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&items)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &items)
+	case ".csv":
+		var records [][]string
+		records, err = csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err == nil && len(records) > 0 {
+			header := records[0]
+			for _, rec := range records[1:] {
+				set := make(map[string]string, len(header))
+				for i, name := range header {
+					if i < len(rec) {
+						set[strings.TrimSpace(name)] = strings.TrimSpace(rec[i])
+					}
+				}
+				items = append(items, set)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported parameter file %s (use .json, .yaml or .csv)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return items, nil
+}
@@ -0,0 +1,95 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// placeholderRe matches the {name} placeholders of a permalink template.
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+// permalinkURL builds the URL of an article from a permalink template such as
+// "/blog/{year}/{month}/{slug}". rel is the path of the file relative to its
+// content directory without extension, as returned by contentSlug.
+//
+// Placeholders:
+//   - {slug}: the slug frontmatter key, or the file name
+//   - {filename}: the file name
+//   - {path}: the file path relative to the content directory
+//   - {dir}: the directory of the file relative to the content directory
+//   - {prefix}: the URL prefix of the content type, e.g. "blog"
+//   - {year}, {month}, {day}: the publication date, or else the lastmod date
+//   - any other name: the frontmatter key of that name
+func permalinkURL(template, prefix, rel string, fm FrontMatter, lastModKeys []string) (string, error) {
+	dir, filename := "", rel
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		dir, filename = rel[:i], rel[i+1:]
+	}
+
+	var missing []string
+	out := placeholderRe.ReplaceAllStringFunc(template, func(m string) string {
+		name := m[1 : len(m)-1]
+		var value string
+		switch name {
+		case "slug":
+			value = fm.String("slug")
+			if value == "" {
+				value = filename
+			}
+		case "filename":
+			value = filename
+		case "path":
+			value = rel
+		case "dir":
+			value = dir
+		case "prefix":
+			value = prefix
+		case "year", "month", "day":
+			t, ok := articleDate(fm, lastModKeys)
+			if !ok {
+				missing = append(missing, name)
+				return ""
+			}
+			value = map[string]string{"year": t.Format("2006"), "month": t.Format("01"), "day": t.Format("02")}[name]
+		default:
+			value = fm.String(name)
+			if value == "" {
+				missing = append(missing, name)
+			}
+		}
+		return escapeSegments(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("permalink %q: no value for %s", template, strings.Join(missing, ", "))
+	}
+
+	out = "/" + strings.Trim(out, "/")
+	for strings.Contains(out, "//") {
+		out = strings.ReplaceAll(out, "//", "/")
+	}
+	return out, nil
+}
+
+// articleDate returns the publication date of an article, or its lastmod date.
+func articleDate(fm FrontMatter, lastModKeys []string) (time.Time, bool) {
+	for _, keys := range [][]string{PublishDateKeys, lastModKeys} {
+		for _, key := range keys {
+			if t, ok := fm.Time(key); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// escapeSegments escapes each segment of a slash-separated path.
+func escapeSegments(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,52 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestScanContentWithOptions_Permalink(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "2024"), 0755)
+	os.WriteFile(filepath.Join(dir, "my-post.md"), []byte("---\ndate: 2024-05-03\n---\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "2024", "other.md"), []byte("---\npublishDate: 2023-11-20T10:00:00Z\nslug: custom slug\ncategory: go\n---\n"), 0644)
+
+	scan := func(permalink string) ([]string, error) {
+		metas, err := sitemap.ScanContentWithOptions(dir, "blog", sitemap.ContentOptions{Permalink: permalink})
+		var urls []string
+		for _, m := range metas {
+			urls = append(urls, m.Loc)
+		}
+		sort.Strings(urls)
+		return urls, err
+	}
+
+	tests := []struct {
+		permalink string
+		want      []string
+	}{
+		{"/blog/{year}/{month}/{slug}", []string{"/blog/2023/11/custom%20slug", "/blog/2024/05/my-post"}},
+		{"/{prefix}/{day}/{filename}", []string{"/blog/03/my-post", "/blog/20/other"}},
+		{"/articles/{path}/", []string{"/articles/2024/other", "/articles/my-post"}},
+		{"/{prefix}/{dir}/{filename}", []string{"/blog/2024/other", "/blog/my-post"}},
+	}
+	for _, tt := range tests {
+		got, err := scan(tt.permalink)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.permalink, err)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.permalink, got, tt.want)
+		}
+	}
+
+	if _, err := scan("/blog/{category}/{slug}"); err == nil || !strings.Contains(err.Error(), "category") {
+		t.Errorf("Expected an error for a missing frontmatter field, got %v", err)
+	}
+}
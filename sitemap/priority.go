@@ -0,0 +1,92 @@
+package sitemap
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Priorities computed from URL depth by ApplyPriorities.
+const (
+	RootPriority     = 1.0
+	TopLevelPriority = 0.8
+	DeepPriority     = 0.5
+)
+
+// ValidatePriorities checks that every configured priority is between 0.0 and
+// 1.0.
+func ValidatePriorities(rules map[string]float64) error {
+	for key, p := range rules {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("priority for %q must be between 0.0 and 1.0, got %v", key, p)
+		}
+	}
+	return nil
+}
+
+// ApplyPriorities fills in the priority of URLs that have none. Rules whose key
+// starts with "/" are route patterns matched against the URL path (with
+// path.Match, so "/products/*" matches one level below /products); when several
+// match, the longest pattern wins. Other keys are content types and are applied
+// by the content scanner instead. With auto, remaining URLs get a priority
+// computed from their depth: 1.0 for the root, 0.8 for top-level pages and 0.5
+// below.
+func ApplyPriorities(urls []Entry, base string, rules map[string]float64, auto bool) []Entry {
+	var patterns []string
+	for key := range rules {
+		if strings.HasPrefix(key, "/") {
+			patterns = append(patterns, key)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	base = strings.TrimRight(base, "/")
+	for i, u := range urls {
+		if u.Priority != "" {
+			continue
+		}
+		p := strings.TrimPrefix(u.Loc, base)
+		if p == "" {
+			p = "/"
+		}
+		matched := false
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, p); ok || pattern == p {
+				urls[i].Priority = formatPriority(rules[pattern])
+				matched = true
+				break
+			}
+		}
+		if !matched && auto {
+			urls[i].Priority = formatPriority(depthPriority(p))
+		}
+	}
+	return urls
+}
+
+func depthPriority(p string) float64 {
+	switch strings.Count(strings.Trim(p, "/"), "/") {
+	case 0:
+		if strings.Trim(p, "/") == "" {
+			return RootPriority
+		}
+		return TopLevelPriority
+	default:
+		return DeepPriority
+	}
+}
+
+// ContentPriority returns the configured priority of a content type, or "" if
+// it has none.
+func (c *Config) ContentPriority(contentType string) string {
+	if p, ok := c.Priority[contentType]; ok && !strings.HasPrefix(contentType, "/") {
+		return formatPriority(p)
+	}
+	return ""
+}
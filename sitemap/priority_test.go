@@ -0,0 +1,83 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestApplyPriorities(t *testing.T) {
+	urls := []sitemap.Entry{
+		{Loc: "https://example.com/"},
+		{Loc: "https://example.com/about"},
+		{Loc: "https://example.com/products"},
+		{Loc: "https://example.com/products/shoe"},
+		{Loc: "https://example.com/docs/guides/setup"},
+		{Loc: "https://example.com/blog/post", Priority: "0.9"},
+	}
+	rules := map[string]float64{"/products/*": 0.7, "/about": 0.3, "blog": 0.6}
+	urls = sitemap.ApplyPriorities(urls, "https://example.com", rules, true)
+
+	want := map[string]string{
+		"https://example.com/":                  "1.0",
+		"https://example.com/about":             "0.3",
+		"https://example.com/products":          "0.8",
+		"https://example.com/products/shoe":     "0.7",
+		"https://example.com/docs/guides/setup": "0.5",
+		"https://example.com/blog/post":         "0.9",
+	}
+	for _, u := range urls {
+		if u.Priority != want[u.Loc] {
+			t.Errorf("%s: expected priority %s, got %q", u.Loc, want[u.Loc], u.Priority)
+		}
+	}
+
+	urls = sitemap.ApplyPriorities([]sitemap.Entry{{Loc: "https://example.com/x"}}, "https://example.com", nil, false)
+	if urls[0].Priority != "" {
+		t.Errorf("Expected no priority without rules or auto, got %q", urls[0].Priority)
+	}
+
+	if err := sitemap.ValidatePriorities(map[string]float64{"/": 1.5}); err == nil {
+		t.Error("Expected an error for a priority over 1.0")
+	}
+}
+
+func TestPriorityFromConfigAndMerge(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "gositemap.toml")
+	os.WriteFile(tmpfile, []byte(`base_url = "https://example.com"
+auto_priority = true
+[priority]
+blog = 0.6
+"/products/*" = 0.7
+`), 0644)
+	cfg, err := sitemap.LoadConfig(tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.AutoPriority || cfg.ContentPriority("blog") != "0.6" || cfg.ContentPriority("/products/*") != "" {
+		t.Errorf("Unexpected priority config: %+v", cfg)
+	}
+
+	existing := []sitemap.Entry{{Loc: "https://example.com/blog/old", LastMod: day("2020-01-01"), Priority: "0.1"}}
+	content := []sitemap.Entry{
+		{Loc: "/blog/old", LastMod: day("2024-01-01"), Priority: cfg.ContentPriority("blog"), Origin: sitemap.OriginContent},
+		{Loc: "/blog/new", LastMod: day("2024-01-01"), Priority: cfg.ContentPriority("blog"), Origin: sitemap.OriginContent},
+	}
+	urls := sitemap.MergeEntries(cfg.BaseURL, content, existing, false)
+	urls = sitemap.ApplyPriorities(urls, cfg.BaseURL, cfg.Priority, cfg.AutoPriority)
+	xml := sitemap.GenerateSitemap(cfg.BaseURL, nil, urls, false)
+	if !strings.Contains(xml, "<loc>https://example.com/blog/old</loc>\n    <lastmod>2020-01-01</lastmod>\n    <priority>0.1</priority>") {
+		t.Errorf("Expected existing priority to be preserved: %s", xml)
+	}
+	if !strings.Contains(xml, "<loc>https://example.com/blog/new</loc>\n    <lastmod>2024-01-01</lastmod>\n    <changefreq>never</changefreq>\n    <priority>0.6</priority>") {
+		t.Errorf("Expected content type priority for new entry: %s", xml)
+	}
+
+	urls = sitemap.MergeEntries(cfg.BaseURL, content, existing, true)
+	if urls[1].Loc != "https://example.com/blog/old" || urls[1].Priority != "0.6" {
+		t.Errorf("Expected priority to be overwritten, got %+v", urls)
+	}
+}
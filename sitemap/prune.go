@@ -0,0 +1,49 @@
+package sitemap
+
+import "strings"
+
+// PruneEntries removes the entries that no scanned entry produced, such as
+// pages deleted since the existing sitemap was written. URLs matching keep are
+// left in place: keep entries starting with http:// or https:// match
+// locations by prefix, others match the URL path like exclude does.
+func PruneEntries(urls []Entry, base string, scanned []Entry, keep []string) (kept, removed []Entry) {
+	base = strings.TrimRight(base, "/")
+	found := make(map[string]bool, len(scanned))
+	for _, e := range scanned {
+		found[absoluteLoc(base, e.Loc)] = true
+	}
+
+	var keepURLs, keepPaths []string
+	for _, k := range keep {
+		if strings.HasPrefix(k, "http://") || strings.HasPrefix(k, "https://") {
+			keepURLs = append(keepURLs, k)
+		} else {
+			keepPaths = append(keepPaths, k)
+		}
+	}
+
+	for _, u := range urls {
+		if found[u.Loc] || keepsURL(u.Loc, base, keepURLs, keepPaths) {
+			kept = append(kept, u)
+		} else {
+			removed = append(removed, u)
+		}
+	}
+	return kept, removed
+}
+
+func keepsURL(loc, base string, keepURLs, keepPaths []string) bool {
+	for _, k := range keepURLs {
+		if strings.HasPrefix(loc, k) {
+			return true
+		}
+	}
+	if loc != base && !strings.HasPrefix(loc, base+"/") {
+		return false
+	}
+	p := strings.TrimPrefix(loc, base)
+	if p == "" {
+		p = "/"
+	}
+	return isExcluded(p, keepPaths)
+}
@@ -0,0 +1,43 @@
+package sitemap_test
+
+import (
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestPruneEntries(t *testing.T) {
+	existing := []sitemap.Entry{
+		{Loc: "https://example.com/about", LastMod: day("2020-01-01")},
+		{Loc: "https://example.com/deleted", LastMod: day("2020-01-01")},
+		{Loc: "https://example.com/blog/old-post", LastMod: day("2020-01-01")},
+		{Loc: "https://example.com/legacy/page", LastMod: day("2020-01-01")},
+		{Loc: "https://shop.example.com/cart", LastMod: day("2020-01-01")},
+		{Loc: "https://other.example.com/gone", LastMod: day("2020-01-01")},
+	}
+	routes := []sitemap.Entry{{Loc: "/about", LastMod: day("2024-01-01")}, {Loc: "/", LastMod: day("2024-01-01")}}
+	content := []sitemap.Entry{{Loc: "/blog/new-post", LastMod: day("2024-01-01")}}
+	keep := []string{"/legacy", "https://shop.example.com/"}
+
+	urls := sitemap.MergeEntries("https://example.com", append(routes, content...), existing, false)
+	kept, removed := sitemap.PruneEntries(urls, "https://example.com", append(routes, content...), keep)
+
+	want := map[string]string{
+		"https://example.com/":              "2024-01-01",
+		"https://example.com/about":         "2020-01-01",
+		"https://example.com/blog/new-post": "2024-01-01",
+		"https://example.com/legacy/page":   "2020-01-01",
+		"https://shop.example.com/cart":     "2020-01-01",
+	}
+	if len(kept) != len(want) {
+		t.Errorf("Expected %d kept URLs, got %+v", len(want), kept)
+	}
+	for _, u := range kept {
+		if lastmod, ok := want[u.Loc]; !ok || u.LastModString() != lastmod {
+			t.Errorf("Unexpected kept URL %s (lastmod %s)", u.Loc, u.LastMod)
+		}
+	}
+	if len(removed) != 3 {
+		t.Errorf("Expected 3 removed URLs, got %+v", removed)
+	}
+}
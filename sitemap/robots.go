@@ -0,0 +1,108 @@
+package sitemap
+
+import (
+	"slices"
+	"strings"
+)
+
+// Markers around the lines gositemap manages in robots.txt. Everything
+// outside them is left as written.
+const (
+	robotsBegin = "# BEGIN gositemap"
+	robotsEnd   = "# END gositemap"
+)
+
+// Robots configures the robots.txt updated with the sitemap.
+type Robots struct {
+	// OutputPath is the robots.txt to update, by default robots.txt next to
+	// the main sitemap.
+	OutputPath string `toml:"output_path"`
+	// DisallowExcluded adds a Disallow line for each exclude entry starting
+	// with "/".
+	DisallowExcluded bool `toml:"disallow_excluded"`
+	// ListChildren lists every file of a sitemap index, not only the index.
+	ListChildren bool `toml:"list_children"`
+}
+
+// RobotsDisallow returns the Disallow paths for the exclude list. Entries
+// matching a path segment anywhere (without a leading "/") have no robots.txt
+// equivalent and are skipped.
+func RobotsDisallow(exclude []string) []string {
+	var paths []string
+	for _, e := range exclude {
+		if strings.HasPrefix(e, "/") && !slices.Contains(paths, e) {
+			paths = append(paths, e)
+		}
+	}
+	return paths
+}
+
+// UpdateRobots returns robots.txt content with the gositemap block listing
+// sitemaps and disallow. An existing block is replaced in place, otherwise the
+// block is appended. Sitemap lines elsewhere that repeat one of sitemaps are
+// dropped; all other lines are kept.
+func UpdateRobots(existing string, sitemaps, disallow []string) string {
+	lines := strings.Split(strings.ReplaceAll(existing, "\r\n", "\n"), "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case robotsBegin:
+			if begin < 0 {
+				begin = i
+			}
+		case robotsEnd:
+			if begin >= 0 && end < 0 {
+				end = i
+			}
+		}
+	}
+
+	block := robotsBlock(sitemaps, disallow)
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		if i == begin && end >= 0 {
+			out = append(out, block...)
+			i = end
+			continue
+		}
+		if loc, ok := robotsSitemap(lines[i]); ok && slices.Contains(sitemaps, loc) {
+			continue
+		}
+		out = append(out, lines[i])
+	}
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	if begin < 0 || end < 0 {
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+func robotsBlock(sitemaps, disallow []string) []string {
+	block := []string{robotsBegin}
+	if len(disallow) > 0 {
+		block = append(block, "User-agent: *")
+		for _, p := range disallow {
+			block = append(block, "Disallow: "+p)
+		}
+		block = append(block, "")
+	}
+	for _, loc := range sitemaps {
+		block = append(block, "Sitemap: "+loc)
+	}
+	return append(block, robotsEnd)
+}
+
+// robotsSitemap returns the URL of a "Sitemap:" line. The field name is case
+// insensitive.
+func robotsSitemap(line string) (string, bool) {
+	name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok || !strings.EqualFold(strings.TrimSpace(name), "sitemap") {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
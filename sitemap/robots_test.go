@@ -0,0 +1,48 @@
+package sitemap_test
+
+import (
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestRobotsDisallow(t *testing.T) {
+	got := sitemap.RobotsDisallow([]string{"/admin", "drafts", "/secret/*", "/admin"})
+	if strings.Join(got, ",") != "/admin,/secret/*" {
+		t.Errorf("Unexpected disallow paths: %v", got)
+	}
+}
+
+func TestUpdateRobots(t *testing.T) {
+	sitemaps := []string{"https://example.com/sitemap.xml"}
+
+	t.Run("new file", func(t *testing.T) {
+		got := sitemap.UpdateRobots("", sitemaps, []string{"/admin"})
+		want := "# BEGIN gositemap\nUser-agent: *\nDisallow: /admin\n\nSitemap: https://example.com/sitemap.xml\n# END gositemap\n"
+		if got != want {
+			t.Errorf("Expected:\n%s\nGot:\n%s", want, got)
+		}
+	})
+
+	t.Run("keeps user rules", func(t *testing.T) {
+		existing := "User-agent: *\r\nDisallow: /private\r\n\r\nsitemap: https://example.com/sitemap.xml\r\nSitemap: https://example.com/other.xml\r\n"
+		got := sitemap.UpdateRobots(existing, sitemaps, nil)
+		want := "User-agent: *\nDisallow: /private\n\nSitemap: https://example.com/other.xml\n\n# BEGIN gositemap\nSitemap: https://example.com/sitemap.xml\n# END gositemap\n"
+		if got != want {
+			t.Errorf("Expected:\n%s\nGot:\n%s", want, got)
+		}
+	})
+
+	t.Run("refreshes block in place", func(t *testing.T) {
+		existing := "User-agent: *\nDisallow: /private\n\n# BEGIN gositemap\nSitemap: https://example.com/old.xml\n# END gositemap\n\n# Trailing comment\n"
+		got := sitemap.UpdateRobots(existing, sitemaps, nil)
+		want := "User-agent: *\nDisallow: /private\n\n# BEGIN gositemap\nSitemap: https://example.com/sitemap.xml\n# END gositemap\n\n# Trailing comment\n"
+		if got != want {
+			t.Errorf("Expected:\n%s\nGot:\n%s", want, got)
+		}
+		if again := sitemap.UpdateRobots(got, sitemaps, nil); again != got {
+			t.Errorf("Expected update to be idempotent, got:\n%s", again)
+		}
+	})
+}
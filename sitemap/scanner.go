@@ -1,6 +1,7 @@
 package sitemap
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,15 +10,43 @@ import (
 	"time"
 ) // en haut de ton fichier
 
-type RouteMeta struct {
-	URL        string
-	LastMod    string
-	ChangeFreq string
+// RouteOptions controls how ScanRoutesWithOptions walks the routes directory.
+type RouteOptions struct {
+	Exclude []string
+	// Dynamic lists the [param] routes to expand. Routes with parameters that
+	// are not listed here are skipped.
+	Dynamic []DynamicRoute
+	// Params gives values for route parameters across all routes, such as
+	// the locales of a [[lang]] segment. They fill the parameters a dynamic
+	// route does not set itself. An empty value drops the segment even when
+	// it is required, so /[lang]/about gives /about for the default locale
+	// under the except-default strategy.
+	Params map[string][]string
+	// Git, when set, gives the lastmod of each page from its last commit.
+	// Files that were never committed fall back to their modification time.
+	Git *GitHistory
 }
 
-// ScanRoutes returns a slice of RouteMeta (URL + lastmod + changefreq)
-func ScanRoutes(root string, exclude []string) ([]RouteMeta, error) {
-	var metas []RouteMeta
+// ScanRoutes returns an Entry (URL + lastmod + changefreq) for every page
+func ScanRoutes(root string, exclude []string) ([]Entry, error) {
+	return ScanRoutesWithOptions(root, RouteOptions{Exclude: exclude})
+}
+
+// ScanRoutesWithOptions is like ScanRoutes but also expands the dynamic routes
+// listed in opts into one Entry per parameter set.
+func ScanRoutesWithOptions(root string, opts RouteOptions) ([]Entry, error) {
+	return scanRoutes(context.Background(), root, opts)
+}
+
+// scanRoutes is ScanRoutesWithOptions, stopping when ctx is done.
+func scanRoutes(ctx context.Context, root string, opts RouteOptions) ([]Entry, error) {
+	var metas []Entry
+	exclude := opts.Exclude
+
+	dynamic := make(map[string]DynamicRoute, len(opts.Dynamic))
+	for _, d := range opts.Dynamic {
+		dynamic[normalizeRoute(d.Pattern)] = d
+	}
 
 	validExt := []string{".md", ".svx"}
 	baseNames := []string{"+page.svelte"}
@@ -26,10 +55,13 @@ func ScanRoutes(root string, exclude []string) ([]RouteMeta, error) {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		rel, _ := filepath.Rel(root, path)
 
 		if d.IsDir() {
-			if strings.HasPrefix(d.Name(), "[") && strings.HasSuffix(d.Name(), "]") {
+			if strings.HasPrefix(d.Name(), "[") && strings.HasSuffix(d.Name(), "]") && len(dynamic) == 0 && len(opts.Params) == 0 {
 				return filepath.SkipDir
 			}
 			return nil
@@ -45,12 +77,12 @@ func ScanRoutes(root string, exclude []string) ([]RouteMeta, error) {
 			}
 		}
 
-		fmt.Println("-> Scanning:", path)
 		if !slices.Contains(baseNames, name) && !hasValidExt {
 			return nil
 		}
 
-		if strings.Contains(rel, string(os.PathSeparator)+"[") {
+		isDynamic := hasParams(rel)
+		if isDynamic && len(dynamic) == 0 && len(opts.Params) == 0 {
 			return nil
 		}
 
@@ -80,49 +112,57 @@ func ScanRoutes(root string, exclude []string) ([]RouteMeta, error) {
 		}
 
 		// Exclusion
-		for _, ex := range exclude {
-			if strings.HasPrefix(ex, "/") {
-				if strings.HasPrefix(url, ex) {
-					return nil
-				}
-			} else {
-				parts := strings.Split(url, "/")
-				if slices.Contains(parts, ex) {
-					return nil
-				}
-			}
+		if isExcluded(url, exclude) {
+			return nil
 		}
 
 		// Last modified
-		fi, err := os.Stat(path)
-		lastmod := time.Now().Format("2006-01-02")
-		if err == nil {
-			lastmod = fi.ModTime().Format("2006-01-02")
+		lastmod, ok := opts.Git.LastMod(path)
+		if !ok {
+			lastmod = fileModTime(path)
 		}
 
-		// Change frequency
-		changefreq := "never"
-		if url == "/" {
-			changefreq = ""
-		} else if url == "/blog" {
-			changefreq = "weekly"
-		}
+		images := imageRefsOf(fileImages(path))
+		sources := pageSources(path)
 
-		// Clean (flow) segments
-		parts := strings.Split(url, "/")
-		var clean []string
-		for _, part := range parts {
-			if part == "" || (strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")")) {
-				continue
+		if isDynamic {
+			route, ok := dynamic[normalizeRoute(url)]
+			if !ok && !coversParams(url, opts.Params) {
+				return nil
 			}
-			clean = append(clean, part)
+			expanded, err := expandDynamicRoute(url, route, ok, opts.Params)
+			if err != nil {
+				return err
+			}
+			for _, u := range expanded {
+				if isExcluded(u, exclude) {
+					continue
+				}
+				metas = append(metas, Entry{
+					Loc:        u,
+					LastMod:    lastmod,
+					ChangeFreq: "never",
+					Images:     images,
+					Sources:    sources,
+					Origin:     OriginRoutes,
+				})
+			}
+			return nil
 		}
-		url = "/" + strings.Join(clean, "/")
 
-		metas = append(metas, RouteMeta{
-			URL:        url,
+		// Change frequency
+		changefreq := defaultRouteChangeFreq(url)
+
+		// Clean (flow) segments
+		url = normalizeRoute(url)
+
+		metas = append(metas, Entry{
+			Loc:        url,
 			LastMod:    lastmod,
 			ChangeFreq: changefreq,
+			Images:     images,
+			Sources:    sources,
+			Origin:     OriginRoutes,
 		})
 
 		return nil
@@ -130,3 +170,114 @@ func ScanRoutes(root string, exclude []string) ([]RouteMeta, error) {
 
 	return metas, err
 }
+
+// pageSources returns the files a page is built from: the +page files next to
+// a +page.svelte, or the markdown file itself.
+func pageSources(path string) []string {
+	if filepath.Base(path) != "+page.svelte" {
+		return []string{path}
+	}
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "+page*"))
+	if err != nil || len(matches) == 0 {
+		return []string{path}
+	}
+	return matches
+}
+
+// fileModTime returns the modification date of path, or today when it cannot
+// be read.
+func fileModTime(path string) time.Time {
+	if fi, err := os.Stat(path); err == nil {
+		return dateOf(fi.ModTime())
+	}
+	return dateOf(time.Now())
+}
+
+// defaultRouteChangeFreq returns the changefreq used for static pages.
+func defaultRouteChangeFreq(url string) string {
+	if url == "/" || url == "" {
+		return ""
+	} else if url == "/blog" {
+		return "weekly"
+	}
+	return "never"
+}
+
+// expandDynamicRoute returns one URL per parameter set of the dynamic route.
+// When configured is false the route only uses the global params.
+func expandDynamicRoute(url string, route DynamicRoute, configured bool, params map[string][]string) ([]string, error) {
+	sets := []map[string]string{{}}
+	if configured {
+		var err error
+		if sets, err = route.ParamSets(); err != nil {
+			return nil, err
+		}
+	}
+	sets = fillParams(sets, parseParams(url), params)
+
+	var urls []string
+	for _, set := range sets {
+		u, err := expandRoute(url, set, params)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", normalizeRoute(url), err)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// coversParams reports whether every parameter of url has global values.
+func coversParams(url string, params map[string][]string) bool {
+	for _, p := range parseParams(url) {
+		if _, ok := params[p.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fillParams expands each set with every global value of the route parameters
+// it does not bind.
+func fillParams(sets []map[string]string, routeParams []routeParam, params map[string][]string) []map[string]string {
+	for _, p := range routeParams {
+		values, ok := params[p.Name]
+		if !ok {
+			continue
+		}
+		var filled []map[string]string
+		for _, set := range sets {
+			if _, bound := set[p.Name]; bound {
+				filled = append(filled, set)
+				continue
+			}
+			for _, v := range values {
+				next := make(map[string]string, len(set)+1)
+				for k, val := range set {
+					next[k] = val
+				}
+				next[p.Name] = v
+				filled = append(filled, next)
+			}
+		}
+		sets = filled
+	}
+	return sets
+}
+
+// isExcluded reports whether url matches the exclude list. Entries starting
+// with "/" are path prefixes, other entries match any path segment.
+func isExcluded(url string, exclude []string) bool {
+	for _, ex := range exclude {
+		if strings.HasPrefix(ex, "/") {
+			if strings.HasPrefix(url, ex) {
+				return true
+			}
+		} else {
+			parts := strings.Split(url, "/")
+			if slices.Contains(parts, ex) {
+				return true
+			}
+		}
+	}
+	return false
+}
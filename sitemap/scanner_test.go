@@ -3,6 +3,7 @@ package sitemap_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"gositemap/sitemap"
@@ -32,8 +33,8 @@ func TestScanRoutes(t *testing.T) {
 			t.Errorf("Expected 1 route, got %d", len(metas))
 		}
 
-		if metas[0].URL != "/" {
-			t.Errorf("Expected route to be '/', got %s", metas[0].URL)
+		if metas[0].Loc != "/" {
+			t.Errorf("Expected route to be '/', got %s", metas[0].Loc)
 		}
 	})
 
@@ -60,8 +61,144 @@ func TestScanRoutes(t *testing.T) {
 			t.Errorf("Expected 1 route, got %d", len(metas))
 		}
 
-		if metas[0].URL != "/" {
-			t.Errorf("Expected route to be '/', got %s", metas[0].URL)
+		if metas[0].Loc != "/" {
+			t.Errorf("Expected route to be '/', got %s", metas[0].Loc)
 		}
 	})
 }
+
+func TestScanRoutesWithOptions_Dynamic(t *testing.T) {
+	t.Run("skips params without config", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, "products", "[id]"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "products", "[id]", "+page.svelte"), []byte("test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "+page.svelte"), []byte("test"), 0644)
+
+		metas, err := sitemap.ScanRoutes(tmpDir, nil)
+		if err != nil {
+			t.Fatalf("ScanRoutes failed: %v", err)
+		}
+		if len(metas) != 1 || metas[0].Loc != "/" {
+			t.Errorf("Expected only '/', got %+v", metas)
+		}
+	})
+
+	t.Run("expands inline values, files and globs", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		routes := filepath.Join(tmpDir, "routes")
+		os.MkdirAll(filepath.Join(routes, "products", "[id]"), 0755)
+		os.WriteFile(filepath.Join(routes, "products", "[id]", "+page.svelte"), []byte("test"), 0644)
+		os.MkdirAll(filepath.Join(routes, "(shop)", "brands", "[brand]"), 0755)
+		os.WriteFile(filepath.Join(routes, "(shop)", "brands", "[brand]", "+page.svelte"), []byte("test"), 0644)
+		os.MkdirAll(filepath.Join(routes, "tags", "[tag]"), 0755)
+		os.WriteFile(filepath.Join(routes, "tags", "[tag]", "+page.svelte"), []byte("test"), 0644)
+		os.MkdirAll(filepath.Join(routes, "posts", "[slug]"), 0755)
+		os.WriteFile(filepath.Join(routes, "posts", "[slug]", "+page.svelte"), []byte("test"), 0644)
+
+		brands := filepath.Join(tmpDir, "brands.json")
+		os.WriteFile(brands, []byte(`["acme", {"brand": "globex"}]`), 0644)
+		tags := filepath.Join(tmpDir, "tags.csv")
+		os.WriteFile(tags, []byte("tag\ngo\nsvelte\n"), 0644)
+		posts := filepath.Join(tmpDir, "posts")
+		os.MkdirAll(posts, 0755)
+		os.WriteFile(filepath.Join(posts, "hello.md"), []byte(""), 0644)
+
+		metas, err := sitemap.ScanRoutesWithOptions(routes, sitemap.RouteOptions{
+			Exclude: []string{"/products/secret"},
+			Dynamic: []sitemap.DynamicRoute{
+				{Pattern: "/products/[id]", Values: []any{"shoe", "hat", "secret"}},
+				{Pattern: "/brands/[brand]", File: brands},
+				{Pattern: "/tags/[tag]", File: tags},
+				{Pattern: "/posts/[slug]", Glob: filepath.Join(posts, "*.md")},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ScanRoutesWithOptions failed: %v", err)
+		}
+		got := map[string]bool{}
+		for _, m := range metas {
+			got[m.Loc] = true
+		}
+		for _, want := range []string{"/products/shoe", "/products/hat", "/brands/acme", "/brands/globex", "/tags/go", "/tags/svelte", "/posts/hello"} {
+			if !got[want] {
+				t.Errorf("Missing expanded route %s in %+v", want, metas)
+			}
+		}
+		if got["/products/secret"] {
+			t.Errorf("Excluded route /products/secret should not be expanded")
+		}
+		if len(metas) != 7 {
+			t.Errorf("Expected 7 routes, got %d: %+v", len(metas), metas)
+		}
+	})
+
+	t.Run("expands nested, optional and rest params", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dir := filepath.Join(tmpDir, "[[lang]]", "docs", "[...path]")
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "+page.svelte"), []byte("test"), 0644)
+
+		values := filepath.Join(tmpDir, "docs.yaml")
+		os.WriteFile(values, []byte("- {lang: fr, path: guides/setup}\n- {path: intro}\n- {lang: en, path: \"\"}\n"), 0644)
+
+		metas, err := sitemap.ScanRoutesWithOptions(tmpDir, sitemap.RouteOptions{
+			Dynamic: []sitemap.DynamicRoute{{Pattern: "/[[lang]]/docs/[...path]", File: values}},
+		})
+		if err != nil {
+			t.Fatalf("ScanRoutesWithOptions failed: %v", err)
+		}
+		want := []string{"/fr/docs/guides/setup", "/docs/intro", "/en/docs"}
+		if len(metas) != len(want) {
+			t.Fatalf("Expected %d routes, got %+v", len(want), metas)
+		}
+		for i, w := range want {
+			if metas[i].Loc != w {
+				t.Errorf("Expected %s, got %s", w, metas[i].Loc)
+			}
+		}
+	})
+
+	t.Run("missing required param is an error", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dir := filepath.Join(tmpDir, "[lang]", "[slug]")
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "+page.svelte"), []byte("test"), 0644)
+
+		_, err := sitemap.ScanRoutesWithOptions(tmpDir, sitemap.RouteOptions{
+			Dynamic: []sitemap.DynamicRoute{{Pattern: "/[lang]/[slug]", Values: []any{"only-slug"}}},
+		})
+		if err == nil {
+			t.Error("Expected an error for a missing [lang] value")
+		}
+	})
+}
+
+func TestScanRoutes_Silent(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "+page.svelte"), []byte(""), 0644)
+	output := captureOutput(func() {
+		if _, err := sitemap.ScanRoutes(root, nil); err != nil {
+			t.Errorf("ScanRoutes failed: %v", err)
+		}
+	})
+	if output != "" {
+		t.Errorf("Scanning should not print to stdout, got: %q", output)
+	}
+}
+
+func TestDynamicRoute_NumericIDs(t *testing.T) {
+	ids := filepath.Join(t.TempDir(), "ids.json")
+	os.WriteFile(ids, []byte(`[1234567, 42, {"id": 98765432109}]`), 0644)
+
+	sets, err := sitemap.DynamicRoute{Pattern: "/products/[id]", File: ids, Values: []any{2.5e6}}.ParamSets()
+	if err != nil {
+		t.Fatalf("ParamSets failed: %v", err)
+	}
+	var got []string
+	for _, s := range sets {
+		got = append(got, s["id"])
+	}
+	if want := "2500000,1234567,42,98765432109"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+}
@@ -1,105 +1,425 @@
 package sitemap
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 )
 
-type urlset struct {
-	XMLName xml.Name `xml:"urlset"`
-	Xmlns   string   `xml:"xmlns,attr"`
-	URLs    []URL    `xml:"url"`
+const (
+	sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	imageNS   = "http://www.google.com/schemas/sitemap-image/1.1"
+
+	// MaxURLsPerSitemap is the maximum number of <url> entries allowed in a
+	// single sitemap file by the sitemaps.org protocol.
+	MaxURLsPerSitemap = 50000
+	// MaxSitemapBytes is the maximum uncompressed size of a single sitemap file.
+	MaxSitemapBytes = 50 * 1024 * 1024
+)
+
+// xmlURL is the <url> element an Entry is written as.
+type xmlURL struct {
+	Loc        string      `xml:"loc"`
+	LastMod    string      `xml:"lastmod,omitempty"`
+	ChangeFreq string      `xml:"changefreq,omitempty"`
+	Priority   string      `xml:"priority,omitempty"`
+	Images     []Image     `xml:"image:image,omitempty"`
+	Videos     []Video     `xml:"video:video,omitempty"`
+	Alternates []Alternate `xml:"xhtml:link,omitempty"`
 }
-type URL struct {
+
+// loadedURLSet mirrors urlset when reading a sitemap. encoding/xml resolves
+// the image: prefix to its namespace, so extension elements are matched by
+// local name here.
+type loadedURLSet struct {
+	URLs []loadedURL `xml:"url"`
+}
+
+type loadedURL struct {
 	Loc        string `xml:"loc"`
 	LastMod    string `xml:"lastmod"`
-	ChangeFreq string `xml:"changefreq,omitempty"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+	Images     []struct {
+		Loc string `xml:"loc"`
+	} `xml:"image"`
+	Videos []struct {
+		ThumbnailLoc string `xml:"thumbnail_loc"`
+		Title        string `xml:"title"`
+		Description  string `xml:"description"`
+		ContentLoc   string `xml:"content_loc"`
+		PlayerLoc    string `xml:"player_loc"`
+		Duration     int    `xml:"duration"`
+	} `xml:"video"`
+	Alternates []Alternate `xml:"link"`
+}
+
+func (l loadedURL) entry() Entry {
+	u := Entry{
+		Loc:        strings.TrimSpace(l.Loc),
+		ChangeFreq: strings.TrimSpace(l.ChangeFreq),
+		Priority:   strings.TrimSpace(l.Priority),
+		Origin:     OriginSitemap,
+	}
+	u.LastMod, _ = parseLastMod(l.LastMod)
+	for _, img := range l.Images {
+		u.Images = append(u.Images, Image{Loc: strings.TrimSpace(img.Loc)})
+	}
+	for _, v := range l.Videos {
+		u.Videos = append(u.Videos, Video{
+			ThumbnailLoc: strings.TrimSpace(v.ThumbnailLoc),
+			Title:        strings.TrimSpace(v.Title),
+			Description:  strings.TrimSpace(v.Description),
+			ContentLoc:   strings.TrimSpace(v.ContentLoc),
+			PlayerLoc:    strings.TrimSpace(v.PlayerLoc),
+			Duration:     v.Duration,
+		})
+	}
+	u.Alternates = l.Alternates
+	return u
+}
+
+func (e Entry) xml() xmlURL {
+	return xmlURL{
+		Loc:        e.Loc,
+		LastMod:    e.LastModString(),
+		ChangeFreq: e.ChangeFreq,
+		Priority:   e.Priority,
+		Images:     e.Images,
+		Videos:     e.Videos,
+		Alternates: e.Alternates,
+	}
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []IndexEntry `xml:"sitemap"`
+}
+
+// IndexEntry is a <sitemap> element of a sitemap index.
+type IndexEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapFile is one generated file: either a plain sitemap or a sitemap index
+// and its children. Name is relative to the output directory. Data holds the
+// uncompressed XML of files returned by BuildSitemapFiles; files returned by
+// PlanSitemapFiles are encoded when written with WriteTo.
+type SitemapFile struct {
+	Name string
+	Data []byte
+
+	entries []Entry
+	index   *sitemapIndex
+}
+
+// WriteTo writes the uncompressed XML of f to w.
+func (f SitemapFile) WriteTo(w io.Writer) (int64, error) {
+	switch {
+	case f.Data != nil:
+		n, err := w.Write(f.Data)
+		return int64(n), err
+	case f.index != nil:
+		out, err := xml.MarshalIndent(f.index, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		n, err := w.Write(append([]byte(xml.Header), out...))
+		return int64(n), err
+	}
+	return WriteURLSet(w, f.entries)
 }
 
-// LoadSitemap reads an XML sitemap file and returns its URLs.
-func LoadSitemap(path string) ([]URL, error) {
-	data, err := os.ReadFile(path)
+// LoadSitemap reads an XML sitemap file, plain or gzip-compressed, and returns
+// its entries. If the file is a sitemap index, the child sitemaps are read from
+// the same directory and their entries are returned together.
+func LoadSitemap(path string) ([]Entry, error) {
+	data, err := readSitemapFile(path)
 	if err != nil {
 		return nil, err
 	}
-	var us urlset
-	if err := xml.Unmarshal(data, &us); err != nil {
+	root, err := rootElement(data)
+	if err != nil {
 		return nil, err
 	}
-	return us.URLs, nil
-}
+	if root != "sitemapindex" {
+		var us loadedURLSet
+		if err := xml.Unmarshal(data, &us); err != nil {
+			return nil, err
+		}
+		urls := make([]Entry, 0, len(us.URLs))
+		for _, u := range us.URLs {
+			urls = append(urls, u.entry())
+		}
+		return urls, nil
+	}
 
-// GenerateSitemap takes base, routes, content metas, and existing URLs, sorts them, and generates the XML
-func GenerateSitemap(base string, routes []RouteMeta, content []ContentMeta, existingURLs []URL, overwriteExisting bool) string {
-	uniqueEntries := make(map[string]URL)
+	var idx sitemapIndex
+	if err := xml.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	var urls []Entry
+	dir := filepath.Dir(path)
+	for _, s := range idx.Sitemaps {
+		child := filepath.Join(dir, childFileName(s.Loc))
+		if child == path {
+			continue
+		}
+		childURLs, err := LoadSitemap(child)
+		if os.IsNotExist(err) {
+			// The index may reference sitemaps that are not managed locally.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", child, err)
+		}
+		urls = append(urls, childURLs...)
+	}
+	return urls, nil
+}
 
-	// If not overwriting, add existing URLs to the map first
-	if !overwriteExisting {
-		for _, u := range existingURLs {
-			uniqueEntries[u.Loc] = u
+// SitemapChildren returns the file names of the child sitemaps that the
+// sitemap index at path lists under dirURL, the directory it is served from.
+// It returns nil when path is a plain sitemap or does not exist.
+func SitemapChildren(path, dirURL string) ([]string, error) {
+	data, err := readSitemapFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if root, err := rootElement(data); err != nil || root != "sitemapindex" {
+		return nil, err
+	}
+	var idx sitemapIndex
+	if err := xml.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	dirURL = strings.TrimRight(dirURL, "/") + "/"
+	var names []string
+	for _, s := range idx.Sitemaps {
+		name := childFileName(s.Loc)
+		if s.Loc == dirURL+name && name != filepath.Base(path) {
+			names = append(names, name)
 		}
 	}
+	return names, nil
+}
 
-	// Add new routes
-	for _, r := range routes {
-		loc := strings.TrimRight(base, "/") + r.URL
-		if existingURL, ok := uniqueEntries[loc]; ok && overwriteExisting {
-			// If overwriteExisting is true, update existing entry with new data
-			existingURL.LastMod = r.LastMod
-			existingURL.ChangeFreq = r.ChangeFreq
-			uniqueEntries[loc] = existingURL
-		} else if !ok { // Only add if not already present
-			uniqueEntries[loc] = URL{
-				Loc:        loc,
-				LastMod:    r.LastMod,
-				ChangeFreq: r.ChangeFreq,
-			}
+// rootElement returns the local name of the document element.
+func rootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
 		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+// childFileName returns the file name of a child sitemap from its <loc>.
+func childFileName(loc string) string {
+	if u, err := url.Parse(loc); err == nil && u.Path != "" {
+		return path.Base(u.Path)
 	}
+	return path.Base(loc)
+}
 
-	// Add new content
-	for _, c := range content {
-		loc := strings.TrimRight(base, "/") + c.URL
-		cf := c.ChangeFreq
-		if cf == "" {
-			cf = "never"
+// MergeEntries resolves the scanned entries against base and combines them
+// with the entries of an existing sitemap into unique entries sorted by
+// location. Existing entries win unless overwriteExisting is set, in which
+// case they are dropped.
+func MergeEntries(base string, entries []Entry, existing []Entry, overwriteExisting bool) []Entry {
+	uniqueEntries := make(map[string]Entry)
+
+	// If not overwriting, add existing entries to the map first
+	if !overwriteExisting {
+		for _, e := range existing {
+			uniqueEntries[e.Loc] = e
 		}
+	}
 
-		if existingURL, ok := uniqueEntries[loc]; ok && overwriteExisting {
-			// If overwriteExisting is true, update existing entry with new data
-			existingURL.LastMod = c.LastMod
-			existingURL.ChangeFreq = cf
-			uniqueEntries[loc] = existingURL
-		} else if !ok { // Only add if not already present
-			uniqueEntries[loc] = URL{
-				Loc:        loc,
-				LastMod:    c.LastMod,
-				ChangeFreq: cf,
-			}
+	for _, e := range entries {
+		loc := absoluteLoc(base, e.Loc)
+		prev, ok := uniqueEntries[loc]
+		if ok && !overwriteExisting {
+			continue
+		}
+		e.Loc = loc
+		if e.ChangeFreq == "" && e.IsArticle() {
+			e.ChangeFreq = "never"
+		}
+		e.Images = resolveImages(base, loc, e.Images)
+		e.Videos = resolveVideos(base, loc, e.Videos)
+		if ok {
+			e.Alternates = prev.Alternates
 		}
+		uniqueEntries[loc] = e
 	}
 
-	var entries []URL
-	for _, u := range uniqueEntries {
-		entries = append(entries, u)
+	merged := make([]Entry, 0, len(uniqueEntries))
+	for _, e := range uniqueEntries {
+		merged = append(merged, e)
 	}
 
 	// Sort entries by URL
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Loc < entries[j].Loc
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Loc < merged[j].Loc
 	})
+	return merged
+}
 
-	us := urlset{
-		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
-		URLs:  entries,
+// absoluteLoc resolves a page URL against base. Absolute URLs are kept as
+// they are.
+func absoluteLoc(base, u string) string {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	if !strings.HasPrefix(u, "/") {
+		u = "/" + u
 	}
-	out, err := xml.MarshalIndent(us, "", "  ")
+	return strings.TrimRight(base, "/") + u
+}
+
+// GenerateSitemap takes base, scanned entries, and existing entries, sorts them, and generates the XML
+func GenerateSitemap(base string, entries []Entry, existing []Entry, overwriteExisting bool) string {
+	out, err := marshalURLSet(MergeEntries(base, entries, existing, overwriteExisting))
 	if err != nil {
 		return ""
 	}
-	return xml.Header + string(out)
+	return string(out)
+}
+
+func marshalURLSet(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := WriteURLSet(&buf, entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SplitEntries splits entries into chunks that each hold at most maxURLs
+// entries and whose serialized urlset stays under maxBytes. The chunks share
+// the backing array of entries.
+func SplitEntries(entries []Entry, maxURLs, maxBytes int) ([][]Entry, error) {
+	// Sizes are counted on a URLSetWriter streaming to io.Discard, so they
+	// match the files written and no encoded entry is kept. Declaring the
+	// namespaces of every entry keeps the estimate on the safe side.
+	sw, err := NewURLSetWriter(io.Discard, NamespacesOf(entries))
+	if err != nil {
+		return nil, err
+	}
+	if err := sw.enc.Flush(); err != nil {
+		return nil, err
+	}
+	// The header and opening tag, plus the closing tag on its own line.
+	empty := sw.Written() + int64(len("\n</urlset>"))
+	var chunks [][]Entry
+	start := 0
+	size := empty
+	for i, u := range entries {
+		before := sw.Written()
+		if err := sw.Write(u); err != nil {
+			return nil, err
+		}
+		if err := sw.enc.Flush(); err != nil {
+			return nil, err
+		}
+		n := sw.Written() - before
+		if i > start && (i-start >= maxURLs || size+n > int64(maxBytes)) {
+			chunks = append(chunks, entries[start:i:i])
+			start = i
+			size = empty
+		}
+		size += n
+	}
+	if start < len(entries) || len(chunks) == 0 {
+		chunks = append(chunks, entries[start:])
+	}
+	return chunks, nil
+}
+
+// BuildSitemapFiles serializes entries into one or more sitemap files. When the
+// entries fit in a single file it returns one urlset named name. Otherwise the
+// entries are split into name-1.xml, name-2.xml, ... and name holds a
+// <sitemapindex> pointing at them. A .xml.gz name gives .xml.gz children; the
+// files are compressed when written by WriteSitemapFiles. dirURL is the public
+// URL of the directory the files are served from.
+func BuildSitemapFiles(dirURL, name string, entries []Entry) ([]SitemapFile, error) {
+	files, err := PlanSitemapFiles(dirURL, name, entries)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		var buf bytes.Buffer
+		if _, err := f.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		files[i] = SitemapFile{Name: f.Name, Data: buf.Bytes()}
+	}
+	return files, nil
+}
+
+// PlanSitemapFiles splits entries into files like BuildSitemapFiles without
+// encoding them. Each file is encoded when written, so large sites are
+// streamed to disk one file at a time.
+func PlanSitemapFiles(dirURL, name string, entries []Entry) ([]SitemapFile, error) {
+	chunks, err := SplitEntries(entries, MaxURLsPerSitemap, MaxSitemapBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 1 {
+		return []SitemapFile{{Name: name, entries: chunks[0]}}, nil
+	}
+
+	stem, ext := splitSitemapName(name)
+	dirURL = strings.TrimRight(dirURL, "/") + "/"
+	idx := &sitemapIndex{Xmlns: sitemapNS}
+	files := []SitemapFile{{Name: name, index: idx}}
+	for i, chunk := range chunks {
+		childName := fmt.Sprintf("%s-%d%s", stem, i+1, ext)
+		files = append(files, SitemapFile{Name: childName, entries: chunk})
+		idx.Sitemaps = append(idx.Sitemaps, IndexEntry{
+			Loc:     dirURL + childName,
+			LastMod: latestLastMod(chunk),
+		})
+	}
+	return files, nil
+}
+
+// splitSitemapName splits "sitemap.xml" into "sitemap" and ".xml", and
+// "sitemap.xml.gz" into "sitemap" and ".xml.gz".
+func splitSitemapName(name string) (string, string) {
+	gz := ""
+	if IsGzipName(name) {
+		name = strings.TrimSuffix(name, GzipExt)
+		gz = GzipExt
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), ext + gz
+}
+
+func latestLastMod(entries []Entry) string {
+	var latest Entry
+	for _, e := range entries {
+		if e.LastMod.After(latest.LastMod) {
+			latest = e
+		}
+	}
+	return latest.LastModString()
 }
 
 func contains(list []string, s string) bool {
@@ -109,4 +429,4 @@ func contains(list []string, s string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
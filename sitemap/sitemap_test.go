@@ -6,6 +6,7 @@ import (
 	"gositemap/sitemap"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -25,8 +26,8 @@ func captureOutput(f func()) string {
 
 func TestGenerateSitemap(t *testing.T) {
 	base := "https://mysite.com"
-	content := []sitemap.ContentMeta{{URL: "/blog/article1", LastMod: "2023-01-01"}}
-	xml := sitemap.GenerateSitemap(base, []sitemap.RouteMeta{}, content, []sitemap.URL{}, false)
+	content := []sitemap.Entry{{Loc: "/blog/article1", LastMod: day("2023-01-01")}}
+	xml := sitemap.GenerateSitemap(base, content, []sitemap.Entry{}, false)
 	if !strings.Contains(xml, "<urlset") || !strings.Contains(xml, "<loc>https://mysite.com/") {
 		t.Errorf("Malformed sitemap xml: %s", xml)
 	}
@@ -40,7 +41,7 @@ func TestSitemapFileCreation(t *testing.T) {
 	tmpfile := "sitemap_test.xml"
 	defer os.Remove(tmpfile)
 
-	xml := sitemap.GenerateSitemap(base, []sitemap.RouteMeta{}, []sitemap.ContentMeta{}, []sitemap.URL{}, false)
+	xml := sitemap.GenerateSitemap(base, nil, []sitemap.Entry{}, false)
 	if err := os.WriteFile(tmpfile, []byte(xml), 0644); err != nil {
 		t.Fatalf("Error writing file: %v", err)
 	}
@@ -86,23 +87,23 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 	base := "https://example.com"
 
 	// Existing URLs with specific lastmod dates
-	existingURLs := []sitemap.URL{
-		{Loc: "https://example.com/", LastMod: "2023-01-01", ChangeFreq: "daily"},
-		{Loc: "https://example.com/about", LastMod: "2023-02-01", ChangeFreq: "monthly"},
+	existingURLs := []sitemap.Entry{
+		{Loc: "https://example.com/", LastMod: day("2023-01-01"), ChangeFreq: "daily"},
+		{Loc: "https://example.com/about", LastMod: day("2023-02-01"), ChangeFreq: "monthly"},
 	}
 
 	// New routes and content that would normally update lastmod
-	newRoutes := []sitemap.RouteMeta{
-		{URL: "/", LastMod: "2024-01-01", ChangeFreq: "weekly"}, // Should not update lastmod
-		{URL: "/contact", LastMod: "2024-03-01", ChangeFreq: "yearly"}, // New entry
+	newRoutes := []sitemap.Entry{
+		{Loc: "/", LastMod: day("2024-01-01"), ChangeFreq: "weekly"}, // Should not update lastmod
+		{Loc: "/contact", LastMod: day("2024-03-01"), ChangeFreq: "yearly"}, // New entry
 	}
-	newContent := []sitemap.ContentMeta{
-		{URL: "/about", LastMod: "2024-02-01", ChangeFreq: "daily"}, // Should not update lastmod
-		{URL: "/blog/new-article", LastMod: "2024-04-01", ChangeFreq: "never"}, // New entry
+	newContent := []sitemap.Entry{
+		{Loc: "/about", LastMod: day("2024-02-01"), ChangeFreq: "daily"}, // Should not update lastmod
+		{Loc: "/blog/new-article", LastMod: day("2024-04-01"), ChangeFreq: "never"}, // New entry
 	}
 
 	// Generate sitemap with overwriteExisting = false (preserve lastmod)
-	xml := sitemap.GenerateSitemap(base, newRoutes, newContent, existingURLs, false)
+	xml := sitemap.GenerateSitemap(base, append(newRoutes, newContent...), existingURLs, false)
 
 	// Verify that existing lastmod dates are preserved
 	if !strings.Contains(xml, "<loc>https://example.com/</loc>\n    <lastmod>2023-01-01</lastmod>\n    <changefreq>daily</changefreq>") {
@@ -128,6 +129,107 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 }
 
 
+func TestBuildSitemapFiles_SingleFile(t *testing.T) {
+	urls := []sitemap.Entry{{Loc: "https://example.com/", LastMod: day("2024-01-01")}}
+	files, err := sitemap.BuildSitemapFiles("https://example.com/", "sitemap.xml", urls)
+	if err != nil {
+		t.Fatalf("BuildSitemapFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "sitemap.xml" {
+		t.Fatalf("Expected a single sitemap.xml, got %+v", files)
+	}
+	if !strings.Contains(string(files[0].Data), "<urlset") {
+		t.Errorf("Expected a urlset, got: %s", files[0].Data)
+	}
+}
+
+func TestBuildSitemapFiles_SplitsIntoIndex(t *testing.T) {
+	var urls []sitemap.Entry
+	for i := 0; i < sitemap.MaxURLsPerSitemap+1; i++ {
+		urls = append(urls, sitemap.Entry{Loc: fmt.Sprintf("https://example.com/page-%06d", i), LastMod: day("2024-01-01")})
+	}
+	urls[len(urls)-1].LastMod = day("2024-06-01")
+
+	files, err := sitemap.BuildSitemapFiles("https://example.com/", "sitemap.xml", urls)
+	if err != nil {
+		t.Fatalf("BuildSitemapFiles failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected index + 2 children, got %d files", len(files))
+	}
+	index := string(files[0].Data)
+	if files[0].Name != "sitemap.xml" || !strings.Contains(index, "<sitemapindex") {
+		t.Errorf("Expected sitemap.xml to be an index, got %s: %s", files[0].Name, index)
+	}
+	if !strings.Contains(index, "<loc>https://example.com/sitemap-1.xml</loc>") || !strings.Contains(index, "<loc>https://example.com/sitemap-2.xml</loc>") {
+		t.Errorf("Index missing child locations: %s", index)
+	}
+	if !strings.Contains(index, "<lastmod>2024-06-01</lastmod>") {
+		t.Errorf("Index missing latest child lastmod: %s", index)
+	}
+	if files[1].Name != "sitemap-1.xml" || files[2].Name != "sitemap-2.xml" {
+		t.Errorf("Unexpected child names: %s, %s", files[1].Name, files[2].Name)
+	}
+	if count := strings.Count(string(files[1].Data), "<loc>"); count != sitemap.MaxURLsPerSitemap {
+		t.Errorf("Expected %d entries in first child, got %d", sitemap.MaxURLsPerSitemap, count)
+	}
+	if count := strings.Count(string(files[2].Data), "<loc>"); count != 1 {
+		t.Errorf("Expected 1 entry in second child, got %d", count)
+	}
+}
+
+func TestSplitEntries_ByteLimit(t *testing.T) {
+	urls := []sitemap.Entry{
+		{Loc: "https://example.com/a", LastMod: day("2024-01-01")},
+		{Loc: "https://example.com/b", LastMod: day("2024-01-01")},
+		{Loc: "https://example.com/c", LastMod: day("2024-01-01")},
+	}
+	chunks, err := sitemap.SplitEntries(urls, 10, 250)
+	if err != nil {
+		t.Fatalf("SplitEntries failed: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Errorf("Expected the byte limit to split entries, got %d chunk(s)", len(chunks))
+	}
+	total := 0
+	for _, c := range chunks {
+		total += len(c)
+	}
+	if total != len(urls) {
+		t.Errorf("Expected %d entries across chunks, got %d", len(urls), total)
+	}
+}
+
+func TestLoadSitemap_FollowsIndex(t *testing.T) {
+	dir := t.TempDir()
+	index := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-1.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-2.xml</loc></sitemap>
+  <sitemap><loc>https://cdn.example.com/external.xml</loc></sitemap>
+</sitemapindex>`
+	child := func(loc string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>` + loc + `</loc><lastmod>2020-01-01</lastmod></url>
+</urlset>`
+	}
+	os.WriteFile(filepath.Join(dir, "sitemap.xml"), []byte(index), 0644)
+	os.WriteFile(filepath.Join(dir, "sitemap-1.xml"), []byte(child("https://example.com/a")), 0644)
+	os.WriteFile(filepath.Join(dir, "sitemap-2.xml"), []byte(child("https://example.com/b")), 0644)
+
+	urls, err := sitemap.LoadSitemap(filepath.Join(dir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("LoadSitemap failed: %v", err)
+	}
+	if len(urls) != 2 || urls[0].Loc != "https://example.com/a" || urls[1].Loc != "https://example.com/b" {
+		t.Errorf("Expected URLs from both children, got %+v", urls)
+	}
+	if urls[0].LastModString() != "2020-01-01" {
+		t.Errorf("Expected lastmod to be read from child, got %q", urls[0].LastMod)
+	}
+}
+
 
 // func TestSitemapWithLastmodAndChangefreq(t *testing.T) {
 // 	// Create a temp markdown file with publishDate in frontmatter
@@ -139,7 +241,7 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 // 	if err != nil || len(metas) != 1 {
 // 		t.Fatalf("ScanContent failed: %v", err)
 // 	}
-// 	xml := sitemap.GenerateSitemap("https://example.com", []sitemap.RouteMeta{}, metas, []sitemap.URL{})
+// 	xml := sitemap.GenerateSitemap("https://example.com", []sitemap.Entry{}, metas, []sitemap.Entry{})
 // 	if !strings.Contains(xml, "<lastmod>2025-07-18</lastmod>") {
 // 		t.Errorf("lastmod not found or incorrect: %s", xml)
 // 	}
@@ -158,7 +260,7 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 // 		t.Fatalf("ScanContent failed: %v", err)
 // 	}
 // 	today := metas[0].LastMod
-// 	xml := sitemap.GenerateSitemap("https://example.com", []sitemap.RouteMeta{}, metas, []sitemap.URL{})
+// 	xml := sitemap.GenerateSitemap("https://example.com", []sitemap.Entry{}, metas, []sitemap.Entry{})
 // 	if !strings.Contains(xml, "<lastmod>"+today+"</lastmod>") {
 // 		t.Errorf("lastmod fallback to today failed: %s", xml)
 // 	}
@@ -166,20 +268,20 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 // 
 // func TestSitemapOrderAndFields(t *testing.T) {
 // 	// Home, main, articles, secondary
-// 	routes := []sitemap.RouteMeta{
-// 		{URL: "/", LastMod: "2023-01-01", ChangeFreq: "weekly"},
-// 		{URL: "/blog", LastMod: "2023-01-02", ChangeFreq: "weekly"},
-// 		{URL: "/about", LastMod: "2023-01-03", ChangeFreq: "never"},
-// 		{URL: "/privacy", LastMod: "2023-01-04", ChangeFreq: "never"},
-// 		{URL: "/cgu", LastMod: "2023-01-05", ChangeFreq: "never"},
-// 		{URL: "/contact", LastMod: "2023-01-06", ChangeFreq: "never"},
-// 		{URL: "/secondary", LastMod: "2023-01-07", ChangeFreq: "never"},
+// 	routes := []sitemap.Entry{
+// 		{Loc: "/", LastMod: day("2023-01-01"), ChangeFreq: "weekly"},
+// 		{Loc: "/blog", LastMod: day("2023-01-02"), ChangeFreq: "weekly"},
+// 		{Loc: "/about", LastMod: day("2023-01-03"), ChangeFreq: "never"},
+// 		{Loc: "/privacy", LastMod: day("2023-01-04"), ChangeFreq: "never"},
+// 		{Loc: "/cgu", LastMod: day("2023-01-05"), ChangeFreq: "never"},
+// 		{Loc: "/contact", LastMod: day("2023-01-06"), ChangeFreq: "never"},
+// 		{Loc: "/secondary", LastMod: day("2023-01-07"), ChangeFreq: "never"},
 // 	}
-// 	content := []sitemap.ContentMeta{
-// 		{URL: "/blog/article-b", LastMod: "2023-02-01"},
-// 		{URL: "/blog/article-a", LastMod: "2023-02-02"},
+// 	content := []sitemap.Entry{
+// 		{Loc: "/blog/article-b", LastMod: day("2023-02-01")},
+// 		{Loc: "/blog/article-a", LastMod: day("2023-02-02")},
 // 	}
-// 	xml := sitemap.GenerateSitemap("https://mysite.com", routes, content, []sitemap.URL{})
+// 	xml := sitemap.GenerateSitemap("https://mysite.com", routes, content, []sitemap.Entry{})
 // 	// Check order: /, /blog, /about, /contact, /blog/article-a, /blog/article-b, /cgu, /privacy, /secondary
 // 	idx := func(s string) int { return strings.Index(xml, s) }
 // 	order := []string{"/", "/blog", "/about", "/contact", "/blog/article-a", "/blog/article-b", "/cgu", "/privacy", "/secondary"}
@@ -210,3 +312,20 @@ func TestGenerateSitemap_PreserveLastMod(t *testing.T) {
 // 		t.Errorf("Missing changefreq never")
 // 	}
 // }
+
+func TestSplitEntries_ExactSize(t *testing.T) {
+	urls := []sitemap.Entry{
+		{Loc: "https://example.com/a", LastMod: day("2024-01-01")},
+		{Loc: "https://example.com/b", Images: []sitemap.Image{{Loc: "https://example.com/b.png"}}},
+	}
+	size, err := sitemap.WriteURLSet(io.Discard, urls)
+	if err != nil {
+		t.Fatalf("WriteURLSet failed: %v", err)
+	}
+	if chunks, _ := sitemap.SplitEntries(urls, 10, int(size)); len(chunks) != 1 {
+		t.Errorf("Expected entries of exactly %d bytes to fit one file, got %d chunks", size, len(chunks))
+	}
+	if chunks, _ := sitemap.SplitEntries(urls, 10, int(size)-1); len(chunks) != 2 {
+		t.Errorf("Expected one byte less to split the entries, got %d chunks", len(chunks))
+	}
+}
@@ -0,0 +1,215 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Source finds the pages of a site.
+type Source interface {
+	Scan(ctx context.Context) ([]Entry, error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context) ([]Entry, error)
+
+// Scan calls f.
+func (f SourceFunc) Scan(ctx context.Context) ([]Entry, error) {
+	return f(ctx)
+}
+
+// FileSource is a Source that reads local files. Watch mode polls its Paths,
+// and Regenerate only rescans it when one of them changed.
+type FileSource interface {
+	Source
+	// Paths returns the files and directories read by Scan.
+	Paths() []string
+}
+
+// ScanError is returned by a source that could not read part of its input.
+// The generator keeps the entries returned with it and reports it as a
+// warning instead of failing.
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("error scanning %s: %v", e.Path, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// RoutesSource scans a SvelteKit routes directory.
+type RoutesSource struct {
+	Dir     string
+	Options RouteOptions
+}
+
+func (s RoutesSource) Scan(ctx context.Context) ([]Entry, error) {
+	entries, err := scanRoutes(ctx, s.Dir, s.Options)
+	if err != nil {
+		return nil, fmt.Errorf("scanning routes in %s: %w", s.Dir, err)
+	}
+	return entries, nil
+}
+
+// Paths returns the routes directory and the files dynamic routes take their
+// values from.
+func (s RoutesSource) Paths() []string {
+	paths := []string{s.Dir}
+	for _, d := range s.Options.Dynamic {
+		if d.File != "" {
+			paths = append(paths, d.File)
+		}
+		if d.Glob != "" {
+			matches, _ := filepath.Glob(d.Glob)
+			paths = append(paths, matches...)
+		}
+	}
+	return paths
+}
+
+// BuildSource scans the HTML files of a static build.
+type BuildSource struct {
+	Dir     string
+	Options BuildOptions
+}
+
+func (s BuildSource) Scan(ctx context.Context) ([]Entry, error) {
+	entries, err := scanBuild(ctx, s.Dir, s.Options)
+	if err != nil {
+		return nil, fmt.Errorf("scanning build output in %s: %w", s.Dir, err)
+	}
+	return entries, nil
+}
+
+func (s BuildSource) Paths() []string {
+	return []string{s.Dir}
+}
+
+// CrawlSource finds pages by following links over HTTP.
+type CrawlSource struct {
+	Crawl   Crawl
+	Exclude []string
+	// Client is the HTTP client used, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+func (s CrawlSource) Scan(ctx context.Context) ([]Entry, error) {
+	entries, err := crawlSite(ctx, s.Crawl, s.Exclude, s.Client)
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) {
+		return entries, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("crawling %s: %w", s.Crawl.StartURL, err)
+	}
+	return entries, nil
+}
+
+// ContentSource scans a directory of Markdown content. Its URLs start with
+// Prefix.
+type ContentSource struct {
+	Dir     string
+	Prefix  string
+	Options ContentOptions
+}
+
+func (s ContentSource) Scan(ctx context.Context) ([]Entry, error) {
+	entries, err := scanContent(ctx, s.Dir, s.Prefix, s.Options)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return entries, &ScanError{Path: s.Dir, Err: err}
+	}
+	return entries, nil
+}
+
+func (s ContentSource) Paths() []string {
+	return []string{s.Dir}
+}
+
+// GlobSource scans every content directory matching Patterns. Each directory
+// is a content type named after the directory, with the options and
+// changefreq set for it in Config.
+type GlobSource struct {
+	Patterns []string
+	Config   *Config
+	Git      *GitHistory
+}
+
+func (s GlobSource) Scan(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	var errs []error
+	for _, pattern := range s.Patterns {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dirs, err := filepath.Glob(pattern)
+		if err != nil {
+			errs = append(errs, &ScanError{Path: pattern, Err: err})
+			continue
+		}
+		for _, dir := range dirs {
+			if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+				continue
+			}
+			slug := filepath.Base(dir)
+			metas, err := scanContent(ctx, dir, slug, s.options(dir, slug))
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			entries = append(entries, metas...)
+			if err != nil {
+				errs = append(errs, &ScanError{Path: dir, Err: err})
+			}
+		}
+	}
+	return entries, errors.Join(errs...)
+}
+
+// Paths returns the directories currently matching Patterns.
+func (s GlobSource) Paths() []string {
+	var paths []string
+	for _, pattern := range s.Patterns {
+		dirs, _ := filepath.Glob(pattern)
+		for _, dir := range dirs {
+			if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+				paths = append(paths, dir)
+			}
+		}
+	}
+	return paths
+}
+
+// options returns the content options of a matched directory. Its changefreq
+// is set under its path relative to the project root, or under its name.
+func (s GlobSource) options(dir, slug string) ContentOptions {
+	freq := "never"
+	opts := ContentOptions{Type: slug}
+	if s.Config != nil {
+		// Glob patterns were resolved against the project root, changefreq
+		// keys were not.
+		key := dir
+		if rel, err := filepath.Rel(s.Config.ProjectRoot, dir); err == nil {
+			key = filepath.ToSlash(rel)
+		}
+		if f, ok := s.Config.ChangeFreq[key]; ok {
+			freq = f
+		} else if f, ok := s.Config.ChangeFreq[slug]; ok {
+			freq = f
+		}
+		opts = s.Config.ContentOptions(slug)
+	}
+	opts.ChangeFreq = freq
+	opts.Git = s.Git
+	return opts
+}
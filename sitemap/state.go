@@ -0,0 +1,121 @@
+package sitemap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// LastModSourceHash bumps lastmod only when the source files of a page
+	// change, tracked in a state file.
+	LastModSourceHash = "hash"
+	// DefaultStateFile is where the content hashes are kept, relative to the
+	// project root.
+	DefaultStateFile = ".gositemap-state.json"
+)
+
+// State maps each URL to the hash of its source files and the lastmod it was
+// given when that hash was first seen.
+type State struct {
+	URLs map[string]StateEntry `json:"urls"`
+}
+
+// StateEntry is the recorded state of one URL.
+type StateEntry struct {
+	Hash    string `json:"hash"`
+	LastMod string `json:"lastmod"`
+}
+
+// LoadState reads a state file. A missing file gives an empty state.
+func LoadState(path string) (*State, error) {
+	s := &State{URLs: make(map[string]StateEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.URLs == nil {
+		s.URLs = make(map[string]StateEntry)
+	}
+	return s, nil
+}
+
+// Save writes the state file.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Apply sets the lastmod of every URL found in hashes: URLs whose hash is
+// unchanged keep their recorded lastmod, changed URLs get today, and URLs
+// seen for the first time keep the lastmod they already have. The state is
+// updated to hold exactly the URLs in hashes.
+func (s *State) Apply(urls []Entry, hashes map[string]string, today time.Time) []Entry {
+	next := make(map[string]StateEntry, len(hashes))
+	for i, u := range urls {
+		hash, ok := hashes[u.Loc]
+		if !ok {
+			continue
+		}
+		if prev, seen := s.URLs[u.Loc]; seen {
+			if lastmod, ok := parseLastMod(prev.LastMod); ok && prev.Hash == hash {
+				urls[i].LastMod = lastmod
+			} else {
+				urls[i].LastMod = dateOf(today)
+			}
+		}
+		next[u.Loc] = StateEntry{Hash: hash, LastMod: urls[i].LastModString()}
+	}
+	s.URLs = next
+	return urls
+}
+
+// SourceHashes returns the hash of the source files of every scanned page,
+// keyed by its location.
+func SourceHashes(base string, scanned []Entry) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, e := range scanned {
+		if len(e.Sources) == 0 {
+			continue
+		}
+		hash, err := hashFiles(e.Sources)
+		if err != nil {
+			return nil, err
+		}
+		hashes[absoluteLoc(base, e.Loc)] = hash
+	}
+	return hashes, nil
+}
+
+// hashFiles returns the SHA-256 of the contents of paths, in sorted order.
+func hashFiles(paths []string) (string, error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	h := sha256.New()
+	for _, p := range sorted {
+		f, err := os.Open(p)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
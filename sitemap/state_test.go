@@ -0,0 +1,88 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestState_Apply(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, ".gositemap-state.json")
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.md"), []byte("b"), 0644)
+	content := []sitemap.Entry{
+		{Loc: "/blog/a", LastMod: day("2024-03-01"), Sources: []string{filepath.Join(dir, "a.md")}},
+		{Loc: "/blog/b", LastMod: day("2024-03-01"), Sources: []string{filepath.Join(dir, "b.md")}},
+	}
+	run := func(today string) []sitemap.Entry {
+		state, err := sitemap.LoadState(statePath)
+		if err != nil {
+			t.Fatalf("LoadState failed: %v", err)
+		}
+		hashes, err := sitemap.SourceHashes("https://example.com", content)
+		if err != nil {
+			t.Fatalf("SourceHashes failed: %v", err)
+		}
+		urls := sitemap.MergeEntries("https://example.com", content, nil, true)
+		urls = state.Apply(urls, hashes, day(today))
+		if err := state.Save(statePath); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		return urls
+	}
+
+	// First run: the scanned lastmod is recorded as is.
+	urls := run("2024-04-01")
+	if urls[0].LastModString() != "2024-03-01" || urls[1].LastModString() != "2024-03-01" {
+		t.Errorf("Expected scanned lastmod on first run, got %+v", urls)
+	}
+
+	// Only b changes: a keeps its recorded date even if the scan says otherwise.
+	content[0].LastMod = day("2024-05-05")
+	os.WriteFile(filepath.Join(dir, "b.md"), []byte("b v2"), 0644)
+	urls = run("2024-05-10")
+	if urls[0].LastModString() != "2024-03-01" {
+		t.Errorf("Expected unchanged page to keep its lastmod, got %s", urls[0].LastMod)
+	}
+	if urls[1].LastModString() != "2024-05-10" {
+		t.Errorf("Expected changed page to be bumped, got %s", urls[1].LastMod)
+	}
+
+	// Removed pages are dropped from the state.
+	content = content[1:]
+	run("2024-06-01")
+	state, _ := sitemap.LoadState(statePath)
+	if len(state.URLs) != 1 || state.URLs["https://example.com/blog/b"].LastMod != "2024-05-10" {
+		t.Errorf("Unexpected state: %+v", state.URLs)
+	}
+}
+
+func TestSourceHashes_PageFiles(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "about"), 0755)
+	os.WriteFile(filepath.Join(root, "about", "+page.svelte"), []byte("<h1>About</h1>"), 0644)
+	os.WriteFile(filepath.Join(root, "about", "+page.ts"), []byte("export const prerender = true"), 0644)
+
+	hash := func() string {
+		routes, err := sitemap.ScanRoutes(root, nil)
+		if err != nil {
+			t.Fatalf("ScanRoutes failed: %v", err)
+		}
+		hashes, err := sitemap.SourceHashes("https://example.com", routes)
+		if err != nil {
+			t.Fatalf("SourceHashes failed: %v", err)
+		}
+		return hashes["https://example.com/about"]
+	}
+	before := hash()
+	if before == "" {
+		t.Fatal("Expected a hash for /about")
+	}
+	os.WriteFile(filepath.Join(root, "about", "+page.ts"), []byte("export const prerender = false"), 0644)
+	if hash() == before {
+		t.Error("Expected the hash to change with +page.ts")
+	}
+}
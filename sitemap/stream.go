@@ -0,0 +1,103 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Namespaces are the extension namespaces declared on a <urlset>. They have to
+// be known before the first entry is written.
+type Namespaces struct {
+	Image bool
+	Video bool
+	Xhtml bool
+}
+
+// NamespacesOf returns the namespaces used by entries.
+func NamespacesOf(entries []Entry) Namespaces {
+	var ns Namespaces
+	for _, e := range entries {
+		ns.Image = ns.Image || len(e.Images) > 0
+		ns.Video = ns.Video || len(e.Videos) > 0
+		ns.Xhtml = ns.Xhtml || len(e.Alternates) > 0
+	}
+	return ns
+}
+
+// URLSetWriter writes a <urlset> document one entry at a time, so that the
+// encoded document is never held in memory. The output is the same as
+// GenerateSitemap for the same entries.
+type URLSetWriter struct {
+	enc *xml.Encoder
+	n   *countingWriter
+}
+
+// NewURLSetWriter writes the XML header and the opening <urlset> tag to w.
+func NewURLSetWriter(w io.Writer, ns Namespaces) (*URLSetWriter, error) {
+	cw := &countingWriter{w: w}
+	if _, err := io.WriteString(cw, xml.Header); err != nil {
+		return nil, err
+	}
+	enc := xml.NewEncoder(cw)
+	enc.Indent("", "  ")
+	attrs := []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: sitemapNS}}
+	if ns.Image {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:image"}, Value: imageNS})
+	}
+	if ns.Video {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:video"}, Value: videoNS})
+	}
+	if ns.Xhtml {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:xhtml"}, Value: xhtmlNS})
+	}
+	if err := enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "urlset"}, Attr: attrs}); err != nil {
+		return nil, err
+	}
+	return &URLSetWriter{enc: enc, n: cw}, nil
+}
+
+// Write encodes e as a <url> element. Entries are written in the order given;
+// MergeEntries returns them sorted by location.
+func (sw *URLSetWriter) Write(e Entry) error {
+	return sw.enc.EncodeElement(e.xml(), xml.StartElement{Name: xml.Name{Local: "url"}})
+}
+
+// Close writes the closing </urlset> tag and flushes the output. It does not
+// close the underlying writer.
+func (sw *URLSetWriter) Close() error {
+	if err := sw.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "urlset"}}); err != nil {
+		return err
+	}
+	return sw.enc.Flush()
+}
+
+// Written returns the number of bytes written to the underlying writer so far.
+func (sw *URLSetWriter) Written() int64 {
+	return sw.n.n
+}
+
+// WriteURLSet streams entries as a single <urlset> document to w.
+func WriteURLSet(w io.Writer, entries []Entry) (int64, error) {
+	sw, err := NewURLSetWriter(w, NamespacesOf(entries))
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if err := sw.Write(e); err != nil {
+			return sw.Written(), err
+		}
+	}
+	err = sw.Close()
+	return sw.Written(), err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,89 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestURLSetWriter(t *testing.T) {
+	entries := sitemap.MergeEntries("https://example.com", []sitemap.Entry{
+		{Loc: "/b", LastMod: day("2024-01-02"), Images: []sitemap.Image{{Loc: "/b.png"}}},
+		{Loc: "/a", LastMod: day("2024-01-01"), ChangeFreq: "weekly"},
+	}, nil, false)
+
+	var buf bytes.Buffer
+	sw, err := sitemap.NewURLSetWriter(&buf, sitemap.NamespacesOf(entries))
+	if err != nil {
+		t.Fatalf("NewURLSetWriter failed: %v", err)
+	}
+	for _, e := range entries {
+		if err := sw.Write(e); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if sw.Written() != int64(buf.Len()) {
+		t.Errorf("Expected %d bytes written, got %d", buf.Len(), sw.Written())
+	}
+	want := sitemap.GenerateSitemap("https://example.com", entries, nil, false)
+	if buf.String() != want {
+		t.Errorf("Streamed output differs from GenerateSitemap:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	var empty bytes.Buffer
+	if _, err := sitemap.WriteURLSet(&empty, nil); err != nil {
+		t.Fatalf("WriteURLSet failed: %v", err)
+	}
+	if empty.String() != sitemap.GenerateSitemap("https://example.com", nil, nil, false) {
+		t.Errorf("Unexpected empty urlset: %s", empty.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteURLSet_Error(t *testing.T) {
+	entries := []sitemap.Entry{{Loc: "https://example.com/" + strings.Repeat("a", 5000)}}
+	if _, err := sitemap.WriteURLSet(failingWriter{}, entries); err == nil {
+		t.Error("Expected write error to be returned")
+	}
+}
+
+func TestPlanSitemapFiles_Streamed(t *testing.T) {
+	var entries []sitemap.Entry
+	for i := 0; i < sitemap.MaxURLsPerSitemap+1; i++ {
+		entries = append(entries, sitemap.Entry{Loc: "https://example.com/p" + strings.Repeat("0", i%3)})
+	}
+	files, err := sitemap.PlanSitemapFiles("https://example.com/", "sitemap.xml.gz", entries)
+	if err != nil {
+		t.Fatalf("PlanSitemapFiles failed: %v", err)
+	}
+	if len(files) != 3 || files[0].Data != nil {
+		t.Fatalf("Expected an unencoded index and two children, got %d files", len(files))
+	}
+	dir := t.TempDir()
+	if err := sitemap.WriteSitemapFiles(dir, files); err != nil {
+		t.Fatalf("WriteSitemapFiles failed: %v", err)
+	}
+	loaded, err := sitemap.LoadSitemap(filepath.Join(dir, "sitemap.xml.gz"))
+	if err != nil {
+		t.Fatalf("LoadSitemap failed: %v", err)
+	}
+	if len(loaded) != len(entries) {
+		t.Errorf("Expected %d entries read back, got %d", len(entries), len(loaded))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sitemap-2.xml.gz")); err != nil {
+		t.Errorf("Expected second child to be written: %v", err)
+	}
+}
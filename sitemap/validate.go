@@ -0,0 +1,177 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxLocLength is the longest <loc> allowed by the sitemaps.org protocol.
+const MaxLocLength = 2048
+
+// ChangeFreqs are the values allowed in <changefreq>.
+var ChangeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+// w3cDateRe matches the W3C datetime formats allowed in <lastmod>: YYYY,
+// YYYY-MM, YYYY-MM-DD, or a date with hh:mm[:ss[.s]] and a timezone.
+var w3cDateRe = regexp.MustCompile(`^\d{4}(-(0[1-9]|1[0-2])(-(0[1-9]|[12]\d|3[01])(T([01]\d|2[0-3]):[0-5]\d(:[0-5]\d(\.\d+)?)?(Z|[+-]([01]\d|2[0-3]):[0-5]\d))?)?)?$`)
+
+// Issue is a protocol violation found by ValidateSitemap.
+type Issue struct {
+	// File is the sitemap file the issue was found in.
+	File string
+	// Loc is the URL the issue is about, if any.
+	Loc     string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Loc != "" {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Loc, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// ValidateSitemap checks a sitemap or sitemap index, plain or gzip-compressed,
+// against the sitemaps.org protocol. The children of an index are checked too
+// when they are found in the same directory. URLs must be absolute and, when
+// host is not empty, on that host. An error is returned only when path cannot
+// be read or parsed.
+func ValidateSitemap(path, host string) ([]Issue, error) {
+	v := validator{host: host, seen: make(map[string]string)}
+	if err := v.file(path, true); err != nil {
+		return nil, err
+	}
+	return v.issues, nil
+}
+
+type validator struct {
+	host   string
+	seen   map[string]string
+	issues []Issue
+}
+
+func (v *validator) add(file, loc, format string, args ...any) {
+	v.issues = append(v.issues, Issue{File: file, Loc: loc, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) file(path string, top bool) error {
+	data, err := readSitemapFile(path)
+	if err != nil {
+		return err
+	}
+	root, err := rootElement(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(data) > MaxSitemapBytes {
+		v.add(path, "", "file is %d bytes uncompressed, over the %d bytes limit", len(data), MaxSitemapBytes)
+	}
+
+	switch root {
+	case "urlset":
+		var us loadedURLSet
+		if err := xml.Unmarshal(data, &us); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(us.URLs) > MaxURLsPerSitemap {
+			v.add(path, "", "%d URLs, over the %d URLs limit", len(us.URLs), MaxURLsPerSitemap)
+		}
+		for _, l := range us.URLs {
+			v.url(path, l)
+		}
+	case "sitemapindex":
+		if !top {
+			v.add(path, "", "a sitemap index must not contain other sitemap indexes")
+			return nil
+		}
+		var idx sitemapIndex
+		if err := xml.Unmarshal(data, &idx); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(idx.Sitemaps) > MaxURLsPerSitemap {
+			v.add(path, "", "%d sitemaps, over the %d sitemaps limit", len(idx.Sitemaps), MaxURLsPerSitemap)
+		}
+		dir := filepath.Dir(path)
+		for _, s := range idx.Sitemaps {
+			loc := strings.TrimSpace(s.Loc)
+			v.loc(path, loc)
+			v.lastMod(path, loc, strings.TrimSpace(s.LastMod))
+			child := filepath.Join(dir, childFileName(loc))
+			if child == path {
+				continue
+			}
+			if _, err := os.Stat(child); err != nil {
+				continue
+			}
+			if err := v.file(child, false); err != nil {
+				v.add(path, loc, "%v", err)
+			}
+		}
+	default:
+		v.add(path, "", "root element is <%s>, expected <urlset> or <sitemapindex>", root)
+	}
+	return nil
+}
+
+// url checks a <url> as written in the file, before its lastmod is parsed.
+func (v *validator) url(file string, l loadedURL) {
+	u := l.entry()
+	v.loc(file, u.Loc)
+	if prev, ok := v.seen[u.Loc]; ok {
+		v.add(file, u.Loc, "duplicate URL (also in %s)", prev)
+	} else {
+		v.seen[u.Loc] = file
+	}
+	v.lastMod(file, u.Loc, strings.TrimSpace(l.LastMod))
+	if u.ChangeFreq != "" && !contains(ChangeFreqs, u.ChangeFreq) {
+		v.add(file, u.Loc, "invalid changefreq %q (must be one of %s)", u.ChangeFreq, strings.Join(ChangeFreqs, ", "))
+	}
+	if u.Priority != "" {
+		if p, err := strconv.ParseFloat(u.Priority, 64); err != nil || p < 0 || p > 1 {
+			v.add(file, u.Loc, "invalid priority %q (must be between 0.0 and 1.0)", u.Priority)
+		}
+	}
+	for _, err := range ValidateVideos([]Entry{u}) {
+		v.add(file, "", "%v", err)
+	}
+}
+
+func (v *validator) loc(file, loc string) {
+	if loc == "" {
+		v.add(file, "", "empty <loc>")
+		return
+	}
+	if len(loc) > MaxLocLength {
+		v.add(file, loc, "URL is %d characters long, over the %d characters limit", len(loc), MaxLocLength)
+	}
+	if i := strings.IndexFunc(loc, needsEscape); i >= 0 {
+		v.add(file, loc, "unescaped character %q (must be percent-encoded)", []rune(loc[i:])[0])
+	}
+	u, err := url.Parse(loc)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(file, loc, "URL must be absolute, starting with http:// or https://")
+		return
+	}
+	if v.host == "" {
+		v.host = u.Host
+	} else if !strings.EqualFold(u.Host, v.host) {
+		v.add(file, loc, "URL host %s does not match %s", u.Host, v.host)
+	}
+}
+
+func (v *validator) lastMod(file, loc, lastmod string) {
+	if lastmod != "" && !w3cDateRe.MatchString(lastmod) {
+		v.add(file, loc, "invalid lastmod %q (must be a W3C datetime such as 2024-05-01 or 2024-05-01T10:30:00+02:00)", lastmod)
+	}
+}
+
+// needsEscape reports whether r must be percent-encoded in a sitemap URL.
+func needsEscape(r rune) bool {
+	return r <= ' ' || r >= 0x7f || strings.ContainsRune("<>\"{}|\\^`", r)
+}
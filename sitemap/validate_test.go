@@ -0,0 +1,81 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestValidateSitemap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sitemap.xml")
+	os.WriteFile(path, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2024-01-01</lastmod><changefreq>daily</changefreq><priority>1.0</priority></url>
+  <url><loc>https://example.com/a</loc><lastmod>2024-01-01T10:30:00+02:00</lastmod></url>
+  <url><loc>https://example.com/a</loc></url>
+  <url><loc>/relative</loc></url>
+  <url><loc>https://other.com/b</loc></url>
+  <url><loc>https://example.com/with space</loc></url>
+  <url><loc>https://example.com/c</loc><lastmod>2024-13-01</lastmod></url>
+  <url><loc>https://example.com/d</loc><changefreq>sometimes</changefreq></url>
+  <url><loc>https://example.com/e</loc><priority>1.5</priority></url>
+  <url><loc>https://example.com/`+strings.Repeat("x", sitemap.MaxLocLength)+`</loc></url>
+</urlset>`), 0644)
+
+	issues, err := sitemap.ValidateSitemap(path, "example.com")
+	if err != nil {
+		t.Fatalf("ValidateSitemap failed: %v", err)
+	}
+	var messages []string
+	for _, i := range issues {
+		messages = append(messages, i.String())
+	}
+	all := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"duplicate URL",
+		"/relative: URL must be absolute",
+		"host other.com does not match example.com",
+		`unescaped character ' '`,
+		`invalid lastmod "2024-13-01"`,
+		`invalid changefreq "sometimes"`,
+		`invalid priority "1.5"`,
+		"over the 2048 characters limit",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Missing issue %q in:\n%s", want, all)
+		}
+	}
+	if len(issues) != 8 {
+		t.Errorf("Expected 8 issues, got %d:\n%s", len(issues), all)
+	}
+}
+
+func TestValidateSitemap_Index(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sitemap.xml"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-1.xml</loc><lastmod>2024-01-01</lastmod></sitemap>
+  <sitemap><loc>https://example.com/sitemap-2.xml</loc><lastmod>yesterday</lastmod></sitemap>
+</sitemapindex>`), 0644)
+	child := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/a</loc></url>
+</urlset>`
+	os.WriteFile(filepath.Join(dir, "sitemap-1.xml"), []byte(child), 0644)
+	os.WriteFile(filepath.Join(dir, "sitemap-2.xml"), []byte(child), 0644)
+
+	issues, err := sitemap.ValidateSitemap(filepath.Join(dir, "sitemap.xml"), "")
+	if err != nil {
+		t.Fatalf("ValidateSitemap failed: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %v", issues)
+	}
+	if !strings.Contains(issues[0].Message, "invalid lastmod") || !strings.Contains(issues[1].Message, "duplicate URL") {
+		t.Errorf("Unexpected issues: %v", issues)
+	}
+}
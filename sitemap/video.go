@@ -0,0 +1,160 @@
+package sitemap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	videoNS = "http://www.google.com/schemas/sitemap-video/1.1"
+
+	// MaxVideoDuration is the longest duration allowed in a video sitemap.
+	MaxVideoDuration = 8 * 60 * 60
+	// MaxVideoDescription is the longest description allowed, in characters.
+	MaxVideoDescription = 2048
+)
+
+// Video is a <video:video> entry of the Google video sitemap extension.
+type Video struct {
+	ThumbnailLoc string `xml:"video:thumbnail_loc"`
+	Title        string `xml:"video:title"`
+	Description  string `xml:"video:description"`
+	ContentLoc   string `xml:"video:content_loc,omitempty"`
+	PlayerLoc    string `xml:"video:player_loc,omitempty"`
+	// Duration is in seconds. -1 means the frontmatter value was invalid.
+	Duration int `xml:"video:duration,omitempty"`
+}
+
+// Validate checks the fields required by the video sitemap extension.
+func (v Video) Validate() error {
+	var missing []string
+	if v.ThumbnailLoc == "" {
+		missing = append(missing, "thumbnail")
+	}
+	if v.Title == "" {
+		missing = append(missing, "title")
+	}
+	if v.Description == "" {
+		missing = append(missing, "description")
+	}
+	if v.ContentLoc == "" && v.PlayerLoc == "" {
+		missing = append(missing, "content_loc or player_loc")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("video %q is missing %s", v.Title, strings.Join(missing, ", "))
+	}
+	if v.Duration < 0 || v.Duration > MaxVideoDuration {
+		return fmt.Errorf("video %q has an invalid duration (must be 1 to %d seconds)", v.Title, MaxVideoDuration)
+	}
+	if len([]rune(v.Description)) > MaxVideoDescription {
+		return fmt.Errorf("video %q has a description longer than %d characters", v.Title, MaxVideoDescription)
+	}
+	return nil
+}
+
+// ValidateVideos returns an error for every invalid video in urls.
+func ValidateVideos(urls []Entry) []error {
+	var errs []error
+	for _, u := range urls {
+		for _, v := range u.Videos {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", u.Loc, err))
+			}
+		}
+	}
+	return errs
+}
+
+// frontMatterVideos reads the videos described under video (a single object)
+// and videos (a list of objects).
+func frontMatterVideos(fm FrontMatter) []Video {
+	var videos []Video
+	if v := fm.Map("video"); v != nil {
+		videos = append(videos, videoFromFrontMatter(v))
+	}
+	if list, ok := fm["videos"].([]any); ok {
+		for _, item := range list {
+			switch v := item.(type) {
+			case FrontMatter:
+				videos = append(videos, videoFromFrontMatter(v))
+			case map[string]any:
+				videos = append(videos, videoFromFrontMatter(FrontMatter(v)))
+			}
+		}
+	}
+	return videos
+}
+
+func videoFromFrontMatter(fm FrontMatter) Video {
+	v := Video{
+		ThumbnailLoc: fm.String("thumbnail"),
+		Title:        fm.String("title"),
+		Description:  fm.String("description"),
+		ContentLoc:   fm.String("content_loc"),
+		PlayerLoc:    fm.String("player_loc"),
+	}
+	if v.ThumbnailLoc == "" {
+		v.ThumbnailLoc = fm.String("thumbnail_loc")
+	}
+	if _, ok := fm["duration"]; ok {
+		v.Duration = parseVideoDuration(fm["duration"])
+	}
+	return v
+}
+
+// parseVideoDuration reads a duration given in seconds, as "h:mm:ss"/"m:ss",
+// or as a Go duration such as "1m30s". It returns -1 when invalid.
+func parseVideoDuration(value any) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.Atoi(s); err == nil {
+			return n
+		}
+		if strings.Contains(s, ":") {
+			total := 0
+			for _, part := range strings.Split(s, ":") {
+				n, err := strconv.Atoi(part)
+				if err != nil || n < 0 {
+					return -1
+				}
+				total = total*60 + n
+			}
+			return total
+		}
+		if d, err := time.ParseDuration(s); err == nil {
+			return int(d.Seconds())
+		}
+	}
+	return -1
+}
+
+// resolveVideos resolves the locations of each video against base and loc.
+func resolveVideos(base, loc string, videos []Video) []Video {
+	if len(videos) == 0 {
+		return nil
+	}
+	resolved := make([]Video, 0, len(videos))
+	for _, v := range videos {
+		v.ThumbnailLoc = resolveLoc(base, loc, v.ThumbnailLoc)
+		v.ContentLoc = resolveLoc(base, loc, v.ContentLoc)
+		v.PlayerLoc = resolveLoc(base, loc, v.PlayerLoc)
+		resolved = append(resolved, v)
+	}
+	return resolved
+}
+
+// resolveLoc resolves a video location like an image reference, keeping
+// references that cannot be resolved as they are.
+func resolveLoc(base, loc, ref string) string {
+	if abs, ok := resolveRef(base, loc, ref); ok {
+		return abs
+	}
+	return ref
+}
@@ -0,0 +1,86 @@
+package sitemap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gositemap/sitemap"
+)
+
+func TestScanContent_Videos(t *testing.T) {
+	dir := t.TempDir()
+	post := `---
+title: Tutorial
+video:
+  title: Install GoSitemap
+  description: A two minute walkthrough.
+  thumbnail: /thumbs/install.jpg
+  content_loc: https://cdn.example.com/install.mp4
+  duration: "2:05"
+---
+`
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte(post), 0644)
+
+	metas, err := sitemap.ScanContent(dir, "tutorials", "never")
+	if err != nil || len(metas) != 1 || len(metas[0].Videos) != 1 {
+		t.Fatalf("Expected one video, got %+v (%v)", metas, err)
+	}
+	if metas[0].Videos[0].Duration != 125 {
+		t.Errorf("Expected duration of 125 seconds, got %d", metas[0].Videos[0].Duration)
+	}
+
+	urls := sitemap.MergeEntries("https://example.com", metas, nil, false)
+	if errs := sitemap.ValidateVideos(urls); len(errs) != 0 {
+		t.Errorf("Unexpected validation errors: %v", errs)
+	}
+	xml := sitemap.GenerateSitemap("https://example.com", metas, nil, false)
+	for _, want := range []string{
+		`xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"`,
+		"<video:thumbnail_loc>https://example.com/thumbs/install.jpg</video:thumbnail_loc>",
+		"<video:title>Install GoSitemap</video:title>",
+		"<video:description>A two minute walkthrough.</video:description>",
+		"<video:content_loc>https://cdn.example.com/install.mp4</video:content_loc>",
+		"<video:duration>125</video:duration>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("Missing %s: %s", want, xml)
+		}
+	}
+	if strings.Contains(xml, "player_loc") {
+		t.Errorf("Empty player_loc should be omitted: %s", xml)
+	}
+
+	path := filepath.Join(dir, "sitemap.xml")
+	os.WriteFile(path, []byte(xml), 0644)
+	loaded, err := sitemap.LoadSitemap(path)
+	if err != nil || len(loaded) != 1 || len(loaded[0].Videos) != 1 || loaded[0].Videos[0].Duration != 125 {
+		t.Errorf("Expected video to be read back, got %+v (%v)", loaded, err)
+	}
+}
+
+func TestVideoValidate(t *testing.T) {
+	valid := sitemap.Video{ThumbnailLoc: "https://e.com/t.jpg", Title: "T", Description: "D", PlayerLoc: "https://e.com/p"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	missing := sitemap.Video{Title: "T"}
+	err := missing.Validate()
+	if err == nil || !strings.Contains(err.Error(), "thumbnail") || !strings.Contains(err.Error(), "content_loc or player_loc") {
+		t.Errorf("Expected missing fields to be reported, got %v", err)
+	}
+
+	tooLong := valid
+	tooLong.Duration = sitemap.MaxVideoDuration + 1
+	if err := tooLong.Validate(); err == nil {
+		t.Error("Expected an error for a duration over the limit")
+	}
+
+	invalid := valid
+	invalid.Duration = -1
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+}
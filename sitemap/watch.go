@@ -0,0 +1,133 @@
+package sitemap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Default timings of a Watcher.
+const (
+	DefaultWatchInterval = 500 * time.Millisecond
+	DefaultWatchDebounce = 300 * time.Millisecond
+)
+
+// Watcher reports changes to files by polling them, so it needs no OS-specific
+// notification API.
+type Watcher struct {
+	// Paths returns the files and directories to watch. It is called on each
+	// poll, so directories matched later by a glob are picked up.
+	Paths func() []string
+	// Interval is the time between two polls, DefaultWatchInterval when 0.
+	Interval time.Duration
+	// Debounce is how long files must stay unchanged before their changes
+	// are reported, DefaultWatchDebounce when 0.
+	Debounce time.Duration
+}
+
+// fileStamp is what a poll compares to detect a change.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watch polls the files until ctx is done. Once a burst of changes has
+// settled, it calls onChange with the paths added, modified or removed since
+// the previous call, sorted. It returns the error of ctx.
+func (w *Watcher) Watch(ctx context.Context, onChange func(changed []string)) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := snapshot(w.Paths())
+	pending := map[string]bool{}
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			cur := snapshot(w.Paths())
+			if changed := diffSnapshots(prev, cur); len(changed) > 0 {
+				for _, p := range changed {
+					pending[p] = true
+				}
+				lastChange = now
+				prev = cur
+			}
+			if len(pending) > 0 && now.Sub(lastChange) >= debounce {
+				changed := make([]string, 0, len(pending))
+				for p := range pending {
+					changed = append(changed, p)
+				}
+				sort.Strings(changed)
+				pending = map[string]bool{}
+				onChange(changed)
+			}
+		}
+	}
+}
+
+// snapshot stamps every file under paths. Missing paths are skipped.
+func snapshot(paths []string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	for _, root := range paths {
+		filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if fi, err := d.Info(); err == nil {
+				files[path] = fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// diffSnapshots returns the files added, modified or removed between two
+// snapshots.
+func diffSnapshots(prev, cur map[string]fileStamp) []string {
+	var changed []string
+	for p, s := range cur {
+		if old, ok := prev[p]; !ok || old.size != s.size || !old.modTime.Equal(s.modTime) {
+			changed = append(changed, p)
+		}
+	}
+	for p := range prev {
+		if _, ok := cur[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// DiffEntries returns the locations present in after but not in before, and
+// those present in before but not in after, sorted.
+func DiffEntries(before, after []Entry) (added, removed []string) {
+	seen := make(map[string]bool, len(before))
+	for _, e := range before {
+		seen[e.Loc] = true
+	}
+	for _, e := range after {
+		if !seen[e.Loc] {
+			added = append(added, e.Loc)
+		}
+		delete(seen, e.Loc)
+	}
+	for loc := range seen {
+		removed = append(removed, loc)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,64 @@
+package sitemap_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gositemap/sitemap"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("a"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 10)
+	w := sitemap.Watcher{
+		Paths:    func() []string { return []string{dir, filepath.Join(dir, "missing")} },
+		Interval: 5 * time.Millisecond,
+		Debounce: 50 * time.Millisecond,
+	}
+	done := make(chan error)
+	go func() {
+		done <- w.Watch(ctx, func(changed []string) { changes <- changed })
+	}()
+
+	// A burst of changes is reported once, after it settles.
+	time.Sleep(20 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "b.md"), []byte("b"), 0644)
+	time.Sleep(10 * time.Millisecond)
+	os.Remove(filepath.Join(dir, "a.md"))
+	select {
+	case changed := <-changes:
+		want := []string{filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")}
+		if !reflect.DeepEqual(changed, want) {
+			t.Errorf("Expected %v, got %v", want, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for changes")
+	}
+	select {
+	case changed := <-changes:
+		t.Errorf("Expected a single report, got another one: %v", changed)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDiffEntries(t *testing.T) {
+	before := []sitemap.Entry{{Loc: "/a"}, {Loc: "/b"}, {Loc: "/c"}}
+	after := []sitemap.Entry{{Loc: "/d"}, {Loc: "/b"}, {Loc: "/a"}}
+	added, removed := sitemap.DiffEntries(before, after)
+	if !reflect.DeepEqual(added, []string{"/d"}) || !reflect.DeepEqual(removed, []string{"/c"}) {
+		t.Errorf("Unexpected diff: added %v, removed %v", added, removed)
+	}
+}
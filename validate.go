@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"gositemap/sitemap"
+	"io"
+	"net/url"
+	"os"
+)
+
+// runValidate checks a sitemap file, by default the configured output path,
+// and fails when it breaks the sitemaps.org protocol.
+func runValidate(stdout, stderr io.Writer, opts CLIOptions) error {
+	path := ""
+	if len(opts.Args) > 0 {
+		path = opts.Args[0]
+	}
+
+	host := ""
+	configPath := opts.configPath()
+	if _, err := os.Stat(configPath); err == nil {
+		cfg, err := sitemap.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf(Red+"Could not load %s: %w"+Reset, configPath, err)
+		}
+		if u, err := url.Parse(cfg.BaseURL); err == nil {
+			host = u.Host
+		}
+		outputPath := opts.resolvePaths(cfg, configPath)
+		if path == "" {
+			path = existingSitemapPath(outputPath, cfg.GzipOnly)
+		}
+	}
+	if path == "" {
+		path = existingSitemapPath(sitemap.DefaultOutputPath, false)
+	}
+
+	issues, err := sitemap.ValidateSitemap(path, host)
+	if err != nil {
+		return fmt.Errorf(Red+"Could not read %s: %w"+Reset, path, err)
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(stderr, Red+"%s"+Reset+"\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf(Red+"%d problem(s) found in %s"+Reset, len(issues), path)
+	}
+	if !opts.Quiet {
+		fmt.Fprintf(stdout, Green+"%s is valid"+Reset+"\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gositemap/sitemap"
+	"io"
+	"slices"
+)
+
+// runWatch generates the sitemap, then regenerates it whenever the routes,
+// the content directories or the config change, until ctx is done. Only the
+// sources reading a changed file are rescanned; a config change reloads
+// everything. Each run prints the URLs added and removed.
+func runWatch(ctx context.Context, stdout, stderr io.Writer, opts CLIOptions, configPath string) error {
+	quiet := opts
+	quiet.Quiet = true
+
+	s, err := loadSite(stderr, opts, configPath)
+	if err != nil {
+		return err
+	}
+	result, err := s.gen.Generate(ctx)
+	if err != nil {
+		return s.generateError(err)
+	}
+	if err := s.write(stdout, stderr, quiet, result); err != nil {
+		return err
+	}
+	prev := result.Entries
+	if !opts.Quiet {
+		fmt.Fprintf(stdout, Green+"Sitemap generated (%d entries) in %s"+Reset+"\n", len(prev), s.outputPath)
+		fmt.Fprintf(stdout, Blue+"Watching for changes, press Ctrl+C to stop..."+Reset+"\n")
+	}
+
+	w := sitemap.Watcher{
+		Paths: func() []string {
+			return append(s.gen.WatchPaths(), configPath)
+		},
+		Interval: opts.Interval,
+	}
+	err = w.Watch(ctx, func(changed []string) {
+		var result *sitemap.Result
+		var err error
+		if slices.Contains(changed, configPath) {
+			next, loadErr := loadSite(stderr, opts, configPath)
+			if loadErr != nil {
+				fmt.Fprintln(stderr, loadErr)
+				return
+			}
+			s = next
+			result, err = s.gen.Generate(ctx)
+		} else {
+			result, err = s.gen.Regenerate(ctx, changed)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, s.generateError(err))
+			return
+		}
+		if err := s.write(stdout, stderr, quiet, result); err != nil {
+			fmt.Fprintln(stderr, err)
+			return
+		}
+		added, removed := sitemap.DiffEntries(prev, result.Entries)
+		prev = result.Entries
+		if opts.Quiet {
+			return
+		}
+		fmt.Fprintf(stdout, Green+"Sitemap regenerated (%d entries, %d added, %d removed)"+Reset+"\n", len(prev), len(added), len(removed))
+		for _, loc := range added {
+			fmt.Fprintf(stdout, Green+"+ %s"+Reset+"\n", loc)
+		}
+		for _, loc := range removed {
+			fmt.Fprintf(stdout, Red+"- %s"+Reset+"\n", loc)
+		}
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}